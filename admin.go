@@ -0,0 +1,252 @@
+package goroom
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// AdminAction identifies an admin API operation for authorization.
+type AdminAction string
+
+const (
+	AdminListRooms  AdminAction = "ListRooms"
+	AdminGetRoom    AdminAction = "GetRoom"
+	AdminSetStatus  AdminAction = "SetStatus"
+	AdminKickPlayer AdminAction = "KickPlayer"
+	AdminBroadcast  AdminAction = "Broadcast"
+	AdminStopRoom   AdminAction = "StopRoom"
+	AdminEvents     AdminAction = "Events"
+)
+
+const defaultMaxBroadcastSize = 64 << 10
+
+type AdminOptions struct {
+	// Authorize is called before every request with the action it would perform. Returning an error rejects the
+	// request with 403 Forbidden. Defaults to allowing everything, so the handler must then be mounted behind the
+	// server's own authentication.
+	Authorize func(r *http.Request, action AdminAction) error
+	// MaxBroadcastSize limits the body of a broadcast request. Defaults to 64KiB.
+	MaxBroadcastSize int64
+	// Slogger defaults to slog.Default().
+	Slogger *slog.Logger
+}
+
+// AdminRoom is the admin API's view of a room.
+type AdminRoom struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Started   bool   `json:"started"`
+	Players   int    `json:"players"`
+	Connected int    `json:"connected"`
+	// QueueDepth is the number of inbound messages waiting for the room loop.
+	QueueDepth int `json:"queueDepth"`
+
+	// Only set when inspecting a single room
+	Presences []AdminPresence `json:"presences,omitempty"`
+	Throttle  *ThrottleStats  `json:"throttle,omitempty"`
+}
+
+type AdminPresence struct {
+	ID          string    `json:"id"`
+	IsConnected bool      `json:"isConnected"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+type adminHandler[RoomId comparable, PlayerID comparable] struct {
+	manager *RoomManager[RoomId, PlayerID]
+	opts    AdminOptions
+	sl      *slog.Logger
+	mux     *http.ServeMux
+}
+
+// NewAdminHandler returns a handler for inspecting and controlling the manager's rooms. Rooms and players are
+// addressed by the string form of their IDs. Mount it with http.StripPrefix:
+//
+//	GET  /rooms                              list rooms
+//	GET  /rooms/{room}                       presences, status and counters for a room
+//	POST /rooms/{room}/status                set the status from {"status": "Locked"}
+//	POST /rooms/{room}/players/{player}/kick close the player's connection
+//	POST /rooms/{room}/broadcast             send the request body to every connected player
+//	POST /rooms/{room}/stop                  stop the room and remove it from the manager
+//	GET  /rooms/{room}/events                stream the room's events, see Room.HandleEvents
+func NewAdminHandler[RoomId comparable, PlayerID comparable](manager *RoomManager[RoomId, PlayerID], opts AdminOptions) http.Handler {
+	if opts.MaxBroadcastSize <= 0 {
+		opts.MaxBroadcastSize = defaultMaxBroadcastSize
+	}
+	sl := opts.Slogger
+	if sl == nil {
+		sl = slog.Default()
+	}
+	h := &adminHandler[RoomId, PlayerID]{
+		manager: manager,
+		opts:    opts,
+		sl:      sl.With("func", "goroom.AdminHandler"),
+		mux:     http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /rooms", h.authorize(AdminListRooms, h.listRooms))
+	h.mux.HandleFunc("GET /rooms/{room}", h.authorize(AdminGetRoom, h.room(h.getRoom)))
+	h.mux.HandleFunc("POST /rooms/{room}/status", h.authorize(AdminSetStatus, h.room(h.setStatus)))
+	h.mux.HandleFunc("POST /rooms/{room}/players/{player}/kick", h.authorize(AdminKickPlayer, h.room(h.kickPlayer)))
+	h.mux.HandleFunc("POST /rooms/{room}/broadcast", h.authorize(AdminBroadcast, h.room(h.broadcast)))
+	h.mux.HandleFunc("POST /rooms/{room}/stop", h.authorize(AdminStopRoom, h.room(h.stopRoom)))
+	h.mux.HandleFunc("GET /rooms/{room}/events", h.authorize(AdminEvents, h.room(h.events)))
+	return h
+}
+
+func (h *adminHandler[RoomId, PlayerID]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) authorize(action AdminAction, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.opts.Authorize != nil {
+			if err := h.opts.Authorize(r, action); err != nil {
+				h.sl.Warn("unauthorized", "action", action, "err", err)
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func (h *adminHandler[RoomId, PlayerID]) room(next func(http.ResponseWriter, *http.Request, *Room[RoomId, PlayerID])) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room, ok := h.manager.lookup(r.PathValue("room"))
+		if !ok {
+			http.Error(w, "room not found", http.StatusNotFound)
+			return
+		}
+		next(w, r, room)
+	}
+}
+
+func (h *adminHandler[RoomId, PlayerID]) listRooms(w http.ResponseWriter, r *http.Request) {
+	rooms := h.manager.Rooms()
+	resp := make([]AdminRoom, 0, len(rooms))
+	for _, room := range rooms {
+		resp = append(resp, adminRoom(room, false))
+	}
+	writeJSON(w, resp)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) getRoom(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	writeJSON(w, adminRoom(room, true))
+}
+
+func (h *adminHandler[RoomId, PlayerID]) setStatus(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	status, err := ParseRoomStatus(body.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.sl.Info("setting status", "room", room.label(), "status", status)
+	room.SetStatus(status)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) kickPlayer(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	player, ok := findPlayer(room, r.PathValue("player"))
+	if !ok {
+		http.Error(w, "player not found", http.StatusNotFound)
+		return
+	}
+	if !room.KickPlayer(player) {
+		http.Error(w, "player not connected", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) broadcast(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.opts.MaxBroadcastSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "message too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.sl.Info("broadcasting", "room", room.label(), "size", len(message))
+	room.SendMessageToAllPlayersContext(r.Context(), message)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) stopRoom(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	h.sl.Info("stopping room", "room", room.label())
+	h.manager.Remove(room.ID)
+	room.Stop()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *adminHandler[RoomId, PlayerID]) events(w http.ResponseWriter, r *http.Request, room *Room[RoomId, PlayerID]) {
+	room.HandleEvents()(w, r)
+}
+
+func adminRoom[RoomId comparable, PlayerID comparable](room *Room[RoomId, PlayerID], detail bool) AdminRoom {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	resp := AdminRoom{
+		ID:         room.metricsLabel,
+		Status:     room.Status.String(),
+		Started:    room.isStarted,
+		Players:    len(room.players),
+		QueueDepth: len(room.messages),
+	}
+	for pid, ss := range room.players {
+		if ss != nil {
+			resp.Connected++
+		}
+		if detail {
+			resp.Presences = append(resp.Presences, AdminPresence{
+				ID:          fmt.Sprint(pid),
+				IsConnected: ss != nil,
+				LastSeen:    room.lastSeen[pid],
+			})
+		}
+	}
+	if detail {
+		slices.SortFunc(resp.Presences, func(a, b AdminPresence) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		stats := room.throttle.stats()
+		resp.Throttle = &stats
+	}
+	return resp
+}
+
+// findPlayer finds a known player by the string form of their ID.
+func findPlayer[RoomId comparable, PlayerID comparable](room *Room[RoomId, PlayerID], label string) (PlayerID, bool) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	for pid := range room.players {
+		if fmt.Sprint(pid) == label {
+			return pid, true
+		}
+	}
+	var zero PlayerID
+	return zero, false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,159 @@
+package goroom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupAdmin(t *testing.T, opts AdminOptions) (http.Handler, *Room[string, string], map[string]*closeTrackingSession) {
+	t.Helper()
+	manager := NewRoomManager[string, string]()
+	room, err := NewRoom[string, string](context.Background(), "lobby", Options[string]{IgnoreCleanup: true})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	if err := manager.Add(room); err != nil {
+		t.Fatalf("Add returned error: %v", err)
+	}
+	sessions := make(map[string]*closeTrackingSession)
+	room.mu.Lock()
+	for _, pid := range []string{"2", "1"} {
+		sessions[pid] = &closeTrackingSession{mockSocketSession: newMockSocketSession(pid), closed: make(chan struct{})}
+		room.players[pid] = sessions[pid]
+	}
+	room.players["3"] = nil
+	room.lastSeen["3"] = time.Now()
+	room.mu.Unlock()
+	return NewAdminHandler(manager, opts), room, sessions
+}
+
+func serveAdmin(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestAdminHandler(t *testing.T) {
+	t.Run("should list rooms", func(t *testing.T) {
+		h, _, _ := setupAdmin(t, AdminOptions{})
+		w := serveAdmin(h, "GET", "/rooms", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+		}
+		var rooms []AdminRoom
+		if err := json.Unmarshal(w.Body.Bytes(), &rooms); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		if len(rooms) != 1 || rooms[0].ID != "lobby" || rooms[0].Players != 3 || rooms[0].Connected != 2 {
+			t.Errorf("unexpected rooms: %+v", rooms)
+		}
+		if rooms[0].Presences != nil {
+			t.Errorf("expected no presences in the listing, got %+v", rooms[0].Presences)
+		}
+		if !strings.Contains(w.Body.String(), `"queueDepth":0`) {
+			t.Errorf("expected the listing to include the queue depth, got %s", w.Body)
+		}
+	})
+	t.Run("should show a room's presences", func(t *testing.T) {
+		h, _, _ := setupAdmin(t, AdminOptions{})
+		w := serveAdmin(h, "GET", "/rooms/lobby", "")
+		var room AdminRoom
+		if err := json.Unmarshal(w.Body.Bytes(), &room); err != nil {
+			t.Fatalf("invalid json: %v", err)
+		}
+		if len(room.Presences) != 3 || room.Presences[0].ID != "1" || room.Presences[2].IsConnected {
+			t.Errorf("unexpected presences: %+v", room.Presences)
+		}
+		if room.Status != "Open" || room.Throttle == nil {
+			t.Errorf("unexpected room: %+v", room)
+		}
+		if w := serveAdmin(h, "GET", "/rooms/missing", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for unknown room, got %d", w.Code)
+		}
+	})
+	t.Run("should set the status", func(t *testing.T) {
+		h, room, _ := setupAdmin(t, AdminOptions{})
+		if w := serveAdmin(h, "POST", "/rooms/lobby/status", `{"status":"Locked"}`); w.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d: %s", w.Code, w.Body)
+		}
+		if room.Status != Locked {
+			t.Errorf("expected room to be locked, got %v", room.Status)
+		}
+		if w := serveAdmin(h, "POST", "/rooms/lobby/status", `{"status":"Closed"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for unknown status, got %d", w.Code)
+		}
+	})
+	t.Run("should kick a player", func(t *testing.T) {
+		h, _, sessions := setupAdmin(t, AdminOptions{})
+		if w := serveAdmin(h, "POST", "/rooms/lobby/players/2/kick", ""); w.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d: %s", w.Code, w.Body)
+		}
+		select {
+		case <-sessions["2"].closed:
+		default:
+			t.Error("expected player 2 to be kicked")
+		}
+		if w := serveAdmin(h, "POST", "/rooms/lobby/players/3/kick", ""); w.Code != http.StatusConflict {
+			t.Errorf("expected 409 for a disconnected player, got %d", w.Code)
+		}
+		if w := serveAdmin(h, "POST", "/rooms/lobby/players/9/kick", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for an unknown player, got %d", w.Code)
+		}
+	})
+	t.Run("should broadcast a system message", func(t *testing.T) {
+		h, _, sessions := setupAdmin(t, AdminOptions{MaxBroadcastSize: 16})
+		if w := serveAdmin(h, "POST", "/rooms/lobby/broadcast", "maintenance"); w.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d: %s", w.Code, w.Body)
+		}
+		for pid, ss := range sessions {
+			if len(ss.sentMessages) != 1 || string(ss.sentMessages[0]) != "maintenance" {
+				t.Errorf("expected player %s to receive the broadcast, got %q", pid, ss.sentMessages)
+			}
+		}
+		if w := serveAdmin(h, "POST", "/rooms/lobby/broadcast", strings.Repeat("x", 17)); w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected 413 for an oversized broadcast, got %d", w.Code)
+		}
+	})
+	t.Run("should stop the room and remove it", func(t *testing.T) {
+		h, room, sessions := setupAdmin(t, AdminOptions{})
+		go room.Start()
+		if w := serveAdmin(h, "POST", "/rooms/lobby/stop", ""); w.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d: %s", w.Code, w.Body)
+		}
+		select {
+		case <-sessions["1"].closed:
+		default:
+			t.Error("expected sessions to be closed")
+		}
+		if w := serveAdmin(h, "GET", "/rooms/lobby", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected stopped room to be removed, got %d", w.Code)
+		}
+	})
+	t.Run("should reject unauthorized actions", func(t *testing.T) {
+		var actions []AdminAction
+		h, _, _ := setupAdmin(t, AdminOptions{
+			Authorize: func(r *http.Request, action AdminAction) error {
+				actions = append(actions, action)
+				if action == AdminStopRoom {
+					return errors.New("read only")
+				}
+				return nil
+			},
+		})
+		if w := serveAdmin(h, "POST", "/rooms/lobby/stop", ""); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403, got %d", w.Code)
+		}
+		if w := serveAdmin(h, "GET", "/rooms", ""); w.Code != http.StatusOK {
+			t.Errorf("expected 200, got %d", w.Code)
+		}
+		if len(actions) != 2 || actions[0] != AdminStopRoom || actions[1] != AdminListRooms {
+			t.Errorf("unexpected authorized actions: %v", actions)
+		}
+	})
+}
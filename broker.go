@@ -0,0 +1,91 @@
+package goroom
+
+import (
+	"context"
+	"sync"
+)
+
+// Broker carries messages between the nodes that host the same logical room, so that players connected to
+// different processes share one room. Set it with Options.Broker; see the redisbroker package for an adapter.
+//
+// Implementations must be safe for concurrent use and must deliver the messages published to a topic to each of its
+// subscribers in the order they were published, including subscribers in the publishing process.
+type Broker interface {
+	// Publish sends payload to every subscriber of topic.
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe calls handle with each payload published to topic until unsubscribe is called. handle is called
+	// on a single goroutine per subscription and must not block for long.
+	Subscribe(ctx context.Context, topic string, handle func(payload []byte)) (unsubscribe func(), err error)
+}
+
+const memoryBrokerBuffer = 1024
+
+// MemoryBroker is a Broker within a single process. It is useful for tests and for running several nodes of a
+// cluster in one process.
+type MemoryBroker struct {
+	mu   sync.RWMutex
+	subs map[string]map[*memorySubscription]struct{}
+}
+
+type memorySubscription struct {
+	ch   chan []byte
+	done chan struct{}
+}
+
+// NewMemoryBroker returns an empty MemoryBroker.
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: make(map[string]map[*memorySubscription]struct{})}
+}
+
+// Publish queues the payload for every subscriber of topic, waiting if a subscriber has fallen far behind.
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mu.RLock()
+	subs := make([]*memorySubscription, 0, len(b.subs[topic]))
+	for sub := range b.subs[topic] {
+		subs = append(subs, sub)
+	}
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		select {
+		case sub.ch <- payload:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(_ context.Context, topic string, handle func(payload []byte)) (func(), error) {
+	sub := &memorySubscription{ch: make(chan []byte, memoryBrokerBuffer), done: make(chan struct{})}
+	b.mu.Lock()
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[*memorySubscription]struct{})
+	}
+	b.subs[topic][sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case payload := <-sub.ch:
+				handle(payload)
+			case <-sub.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[topic], sub)
+			if len(b.subs[topic]) == 0 {
+				delete(b.subs, topic)
+			}
+			b.mu.Unlock()
+			close(sub.done)
+		})
+	}, nil
+}
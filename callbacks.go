@@ -0,0 +1,144 @@
+package goroom
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+type PanicPolicy int
+
+const (
+	// PanicLog logs the panic, reports it to OnError and carries on.
+	PanicLog PanicPolicy = iota
+	// PanicKickPlayer additionally closes the connection of the player whose callback panicked.
+	PanicKickPlayer
+	// PanicStopRoom additionally stops the whole room.
+	PanicStopRoom
+)
+
+func (p PanicPolicy) String() string {
+	switch p {
+	case PanicLog:
+		return "Log"
+	case PanicKickPlayer:
+		return "KickPlayer"
+	case PanicStopRoom:
+		return "StopRoom"
+	default:
+		return "Unknown"
+	}
+}
+
+// PanicError is the error passed to Options.OnError when a callback panics.
+type PanicError struct {
+	Callback string
+	Value    any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("goroom: panic in %s: %v", e.Callback, e.Value)
+}
+
+// callbackGroup counts the callbacks in flight. Unlike a sync.WaitGroup, callbacks may start while another goroutine
+// waits for the count to reach zero, as happens when a player is removed while the room drains or stops.
+type callbackGroup struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{}
+}
+
+func (g *callbackGroup) add() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.count == 0 {
+		g.idle = make(chan struct{})
+	}
+	g.count++
+}
+
+func (g *callbackGroup) done() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.count--
+	if g.count == 0 {
+		close(g.idle)
+	}
+}
+
+// wait returns a channel that is closed once no callbacks are in flight.
+func (g *callbackGroup) wait() <-chan struct{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.count == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return g.idle
+}
+
+// goCallback runs fn on a new goroutine with panic recovery. Drain waits for it to finish.
+func (room *Room[RoomId, PlayerID]) goCallback(ctx context.Context, name string, player PlayerID, fn func(ctx context.Context)) {
+	room.callbacks.add()
+	go func() {
+		defer room.callbacks.done()
+		room.runCallback(ctx, name, player, fn)
+	}()
+}
+
+// runCallback runs fn inside a span named after the callback, recovering any panic and applying the room's
+// PanicPolicy.
+func (room *Room[RoomId, PlayerID]) runCallback(ctx context.Context, name string, player PlayerID, fn func(ctx context.Context)) {
+	ctx, span := room.tracer.Start(ctx, "goroom."+name,
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.player", player),
+	)
+	defer span.End()
+	defer func() {
+		if r := recover(); r != nil {
+			err := &PanicError{Callback: name, Value: r}
+			span.RecordError(err)
+			room.handlePanic(err, player, debug.Stack())
+		}
+	}()
+	fn(ctx)
+}
+
+func (room *Room[RoomId, PlayerID]) handlePanic(err *PanicError, player PlayerID, stack []byte) {
+	sl := room.Slogger.With("func", "room.handlePanic")
+	sl.Error("callback panicked", "player", player, "callback", err.Callback, "panic", fmt.Sprint(err.Value), "policy", room.opts.PanicPolicy)
+
+	if room.opts.OnError != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					sl.Error("OnError panicked", "player", player, "panic", fmt.Sprint(r))
+				}
+			}()
+			room.opts.OnError(player, err, stack)
+		}()
+	}
+
+	switch room.opts.PanicPolicy {
+	case PanicKickPlayer:
+		room.KickPlayer(player)
+	case PanicStopRoom:
+		go room.Stop()
+	}
+}
+
+// KickPlayer closes the player's connection. The player remains known to the room and is handled as a normal
+// disconnect. It reports whether the player was connected.
+func (room *Room[RoomId, PlayerID]) KickPlayer(player PlayerID) bool {
+	room.mu.RLock()
+	ss := room.players[player]
+	room.mu.RUnlock()
+	if ss == nil {
+		return false
+	}
+	room.Slogger.Info("kicking player", "func", "room.KickPlayer", "player", player)
+	ss.Close()
+	return true
+}
@@ -0,0 +1,152 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// closeTrackingSession records when Close is called.
+type closeTrackingSession struct {
+	*mockSocketSession[string]
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *closeTrackingSession) Close() {
+	c.once.Do(func() { close(c.closed) })
+}
+
+func TestRoom_CallbackPanics(t *testing.T) {
+	type reported struct {
+		player string
+		err    error
+		stack  []byte
+	}
+	setup := func(t *testing.T, policy PanicPolicy) (*Room[string, string], chan reported) {
+		errs := make(chan reported, 1)
+		room, err := NewRoom[string, string](context.Background(), "test-room-panic", Options[string]{
+			OnConnect:    func(player string) { panic("connect") },
+			OnDisconnect: func(player string) { panic("disconnect") },
+			OnMessage:    func(player string, message []byte) { panic("message") },
+			OnRemove:     func(player string) { panic("remove") },
+			OnError: func(player string, err error, stack []byte) {
+				errs <- reported{player, err, stack}
+			},
+			PanicPolicy:   policy,
+			IgnoreCleanup: true,
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		return room, errs
+	}
+	waitFor := func(t *testing.T, errs chan reported) reported {
+		t.Helper()
+		select {
+		case r := <-errs:
+			return r
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnError")
+		}
+		return reported{}
+	}
+
+	t.Run("should recover a panicking OnMessage and report it", func(t *testing.T) {
+		room, errs := setup(t, PanicLog)
+		go room.Start()
+		defer room.Stop()
+
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Message, Message: []byte("hi")}
+
+		r := waitFor(t, errs)
+		if r.player != "player-1" {
+			t.Errorf("expected player-1, got %s", r.player)
+		}
+		var pe *PanicError
+		if !errors.As(r.err, &pe) || pe.Callback != "OnMessage" || pe.Value != "message" {
+			t.Errorf("expected PanicError from OnMessage, got %v", r.err)
+		}
+		if len(r.stack) == 0 {
+			t.Error("expected a stack trace")
+		}
+	})
+	t.Run("should recover panics in OnDisconnect and OnRemove", func(t *testing.T) {
+		room, errs := setup(t, PanicLog)
+		go room.Start()
+		defer room.Stop()
+
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Disconnect}
+		if r := waitFor(t, errs); r.err.(*PanicError).Callback != "OnDisconnect" {
+			t.Errorf("expected OnDisconnect panic, got %v", r.err)
+		}
+
+		room.SetStatus(Locked)
+		if r := waitFor(t, errs); r.err.(*PanicError).Callback != "OnRemove" {
+			t.Errorf("expected OnRemove panic, got %v", r.err)
+		}
+	})
+	t.Run("should kick the player when the policy is PanicKickPlayer", func(t *testing.T) {
+		room, errs := setup(t, PanicKickPlayer)
+		ss := &closeTrackingSession{mockSocketSession: newMockSocketSession[string]("player-1"), closed: make(chan struct{})}
+		room.players["player-1"] = ss
+
+		room.runCallback(context.Background(), "OnConnect", "player-1", func(context.Context) { room.opts.OnConnect("player-1") })
+
+		waitFor(t, errs)
+		select {
+		case <-ss.closed:
+		case <-time.After(time.Second):
+			t.Fatal("expected player session to be closed")
+		}
+	})
+	t.Run("should stop the room when the policy is PanicStopRoom", func(t *testing.T) {
+		room, errs := setup(t, PanicStopRoom)
+		go room.Start()
+
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Message, Message: []byte("hi")}
+
+		waitFor(t, errs)
+		select {
+		case <-room.ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected room to be stopped")
+		}
+	})
+}
+
+func TestCallbackGroup(t *testing.T) {
+	var g callbackGroup
+	select {
+	case <-g.wait():
+	default:
+		t.Fatal("expected an empty group to be idle")
+	}
+
+	g.add()
+	idle := g.wait()
+	// Callbacks may start while another goroutine waits.
+	g.add()
+	g.done()
+	select {
+	case <-idle:
+		t.Fatal("expected the group to be busy while a callback runs")
+	default:
+	}
+	g.done()
+	select {
+	case <-idle:
+	default:
+		t.Fatal("expected the group to be idle once every callback is done")
+	}
+
+	g.add()
+	select {
+	case <-g.wait():
+		t.Fatal("expected the group to be busy again")
+	default:
+	}
+	g.done()
+}
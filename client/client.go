@@ -0,0 +1,403 @@
+// Package client connects Go programs such as bots, load tests and integration tests to a goroom room over
+// websockets.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chilledoj/goroom"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// ResumeTokenHeader is the response header a server may set on the websocket upgrade. The client sends the latest
+// token back in the same request header when it reconnects, so the server can resume the player's session.
+const ResumeTokenHeader = "Goroom-Resume-Token"
+
+var ErrClosed = errors.New("client closed")
+
+const (
+	defaultMinBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff   = 10 * time.Second
+	defaultPingInterval = 10 * time.Second
+	defaultBuffer       = 255
+)
+
+// Options configures a Client. Every field is optional.
+type Options struct {
+	// Header is sent with every connection attempt, for example for authentication.
+	Header http.Header
+	// Codec is used by SendValue and Decode. Defaults to goroom.JSONCodec.
+	Codec goroom.Codec
+
+	// DisableReconnect closes the client when the connection drops rather than reconnecting.
+	DisableReconnect bool
+	// MinBackoff and MaxBackoff bound the jittered exponential backoff between reconnection attempts. They default
+	// to 100ms and 10s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts limits consecutive failed reconnection attempts. Defaults to unlimited.
+	MaxAttempts int
+
+	// PingInterval is how often the client pings the server to measure RTT. Defaults to 10 seconds.
+	PingInterval time.Duration
+	// Buffer is the size of the Receive channel. Defaults to 255.
+	Buffer int
+
+	// OnConnect is called after every successful connection, including reconnections.
+	OnConnect func()
+	// OnDisconnect is called with the reason whenever the connection drops.
+	OnDisconnect func(err error)
+
+	// Slogger defaults to slog.Default().
+	Slogger *slog.Logger
+}
+
+// Client is a connection to a room that reconnects when it drops. It is safe for concurrent use.
+type Client struct {
+	url  string
+	opts Options
+	sl   *slog.Logger
+
+	recv chan []byte
+	rtt  atomic.Int64
+
+	mu          sync.Mutex
+	conn        net.Conn
+	resumeToken string
+	err         error
+
+	writeMu sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Dial connects to the room's websocket URL. The context only bounds the initial connection attempt; use Close to
+// end the client.
+func Dial(ctx context.Context, url string, opts Options) (*Client, error) {
+	if opts.Codec == nil {
+		opts.Codec = goroom.JSONCodec{}
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = defaultPingInterval
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultBuffer
+	}
+	sl := opts.Slogger
+	if sl == nil {
+		sl = slog.Default()
+	}
+	c := &Client{
+		url:  url,
+		opts: opts,
+		sl:   sl.With("url", url),
+		recv: make(chan []byte, opts.Buffer),
+		done: make(chan struct{}),
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+
+	conn, br, err := c.dial(ctx)
+	if err != nil {
+		c.cancel()
+		return nil, err
+	}
+	go c.run(conn, br)
+	return c, nil
+}
+
+// Receive returns the channel of messages from the room. It is closed when the client closes or gives up
+// reconnecting, after which Err reports why.
+func (c *Client) Receive() <-chan []byte {
+	return c.recv
+}
+
+// Send sends a message to the room. It returns goroom.ErrNotConnected while reconnecting and ErrClosed after the
+// client has closed.
+func (c *Client) Send(message []byte) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if c.ctx.Err() != nil {
+		return ErrClosed
+	}
+	if conn == nil {
+		return goroom.ErrNotConnected
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return wsutil.WriteClientBinary(conn, message)
+}
+
+// SendValue encodes v with the client's codec and sends it.
+func (c *Client) SendValue(v any) error {
+	data, err := c.opts.Codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Send(data)
+}
+
+// Decode decodes a received message with the client's codec.
+func (c *Client) Decode(message []byte, v any) error {
+	return c.opts.Codec.Unmarshal(message, v)
+}
+
+// RTT is the round trip time of the latest ping, or zero before the first pong.
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rtt.Load())
+}
+
+// Connected reports whether the client currently has a connection.
+func (c *Client) Connected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Err returns the reason the client stopped, once Receive has been closed.
+func (c *Client) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close sends a normal closure to the server and stops the client, waiting for it to finish.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn != nil {
+		c.writeMu.Lock()
+		_ = ws.WriteFrame(conn, ws.MaskFrameInPlace(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusNormalClosure, ""))))
+		c.writeMu.Unlock()
+	}
+	c.cancel()
+	if conn != nil {
+		conn.Close()
+	}
+	<-c.done
+	return nil
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	header := c.opts.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	c.mu.Lock()
+	if c.resumeToken != "" {
+		header.Set(ResumeTokenHeader, c.resumeToken)
+	}
+	c.mu.Unlock()
+
+	var token string
+	dialer := ws.Dialer{
+		Header: ws.HandshakeHeaderHTTP(header),
+		OnHeader: func(key, value []byte) error {
+			if http.CanonicalHeaderKey(string(key)) == ResumeTokenHeader {
+				token = string(value)
+			}
+			return nil
+		},
+	}
+	conn, br, _, err := dialer.Dial(ctx, c.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	if token != "" {
+		c.resumeToken = token
+	}
+	c.mu.Unlock()
+	c.sl.Debug("connected")
+	if c.opts.OnConnect != nil {
+		c.opts.OnConnect()
+	}
+	return conn, br, nil
+}
+
+// run serves connections until the client closes or a reconnection fails for good.
+func (c *Client) run(conn net.Conn, br *bufio.Reader) {
+	var err error
+	defer func() {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		c.cancel()
+		close(c.recv)
+		close(c.done)
+	}()
+	for {
+		err = c.serve(conn, br)
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		if c.ctx.Err() != nil {
+			err = ErrClosed
+			return
+		}
+		c.sl.Debug("disconnected", "err", err)
+		if c.opts.OnDisconnect != nil {
+			c.opts.OnDisconnect(err)
+		}
+		if c.opts.DisableReconnect || !retryable(err) {
+			return
+		}
+		if conn, br, err = c.reconnect(); err != nil {
+			return
+		}
+	}
+}
+
+// retryable reports whether the client should reconnect after the connection ended with err.
+func retryable(err error) bool {
+	var closed wsutil.ClosedError
+	if errors.As(err, &closed) {
+		// The server closed the connection on purpose. Going away is used when draining for a restart.
+		return closed.Code == ws.StatusGoingAway
+	}
+	return true
+}
+
+func (c *Client) reconnect() (net.Conn, *bufio.Reader, error) {
+	for attempt := 0; c.opts.MaxAttempts == 0 || attempt < c.opts.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-c.ctx.Done():
+			return nil, nil, ErrClosed
+		}
+		conn, br, err := c.dial(c.ctx)
+		if err == nil {
+			return conn, br, nil
+		}
+		c.sl.Debug("reconnect failed", "attempt", attempt+1, "err", err)
+		var status ws.StatusError
+		if errors.As(err, &status) && status >= 400 && status < 500 {
+			// The server refused the player, so retrying will not help.
+			return nil, nil, err
+		}
+		if c.ctx.Err() != nil {
+			return nil, nil, ErrClosed
+		}
+	}
+	return nil, nil, errors.New("client: too many reconnection attempts")
+}
+
+// backoff returns a jittered exponential delay for the attempt.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.MinBackoff << min(attempt, 30)
+	if d <= 0 || d > c.opts.MaxBackoff {
+		d = c.opts.MaxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// serve reads from the connection until it ends, pinging the server meanwhile.
+func (c *Client) serve(conn net.Conn, br *bufio.Reader) error {
+	var src io.Reader = conn
+	if br != nil {
+		src = br
+		defer ws.PutReader(br)
+	}
+	// Close may have run while this connection was being dialed, when it had no connection to close.
+	stopClosing := context.AfterFunc(c.ctx, func() { conn.Close() })
+	defer stopClosing()
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.ping(conn, stop)
+
+	dst := writerFunc(func(p []byte) (int, error) {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		return conn.Write(p)
+	})
+	rd := wsutil.Reader{
+		Source:    src,
+		State:     ws.StateClientSide,
+		CheckUTF8: true,
+	}
+	control := func(hdr ws.Header, r io.Reader) error {
+		if hdr.OpCode == ws.OpPong {
+			payload, err := io.ReadAll(r)
+			if err == nil && len(payload) == 8 {
+				sent := int64(binary.BigEndian.Uint64(payload))
+				c.rtt.Store(time.Now().UnixNano() - sent)
+			}
+			return err
+		}
+		return wsutil.ControlHandler{Src: r, Dst: dst, State: ws.StateClientSide, DisableSrcCiphering: true}.Handle(hdr)
+	}
+	rd.OnIntermediate = func(hdr ws.Header, r io.Reader) error { return control(hdr, r) }
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return err
+		}
+		if hdr.OpCode.IsControl() {
+			if err := control(hdr, &rd); err != nil {
+				return err
+			}
+			continue
+		}
+		if hdr.OpCode&(ws.OpText|ws.OpBinary) == 0 {
+			if err := rd.Discard(); err != nil {
+				return err
+			}
+			continue
+		}
+		msg, err := io.ReadAll(&rd)
+		if err != nil {
+			return err
+		}
+		select {
+		case c.recv <- msg:
+		case <-c.ctx.Done():
+			return ErrClosed
+		}
+	}
+}
+
+func (c *Client) ping(conn net.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(c.opts.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			var payload [8]byte
+			binary.BigEndian.PutUint64(payload[:], uint64(time.Now().UnixNano()))
+			c.writeMu.Lock()
+			err := wsutil.WriteClientMessage(conn, ws.OpPing, payload[:])
+			c.writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
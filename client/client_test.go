@@ -0,0 +1,246 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+	"github.com/chilledoj/goroom/client"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+func setupRoom(t *testing.T, opts goroom.Options[string]) (*goroom.Room[string, string], string) {
+	t.Helper()
+	room, err := goroom.NewRoom[string, string](context.Background(), "client-test", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		room.HandleSocketWithPlayer(r.Header.Get("Player"), func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		})(w, r)
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		room.Stop()
+	})
+	return room, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url, player string, opts client.Options) *client.Client {
+	t.Helper()
+	opts.Header = http.Header{"Player": {player}}
+	c, err := client.Dial(context.Background(), url, opts)
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func receive(t *testing.T, c *client.Client) []byte {
+	t.Helper()
+	select {
+	case msg, ok := <-c.Receive():
+		if !ok {
+			t.Fatalf("client closed: %v", c.Err())
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a message")
+	}
+	return nil
+}
+
+type move struct {
+	X, Y int
+}
+
+func TestClient_Messages(t *testing.T) {
+	var room *goroom.Room[string, string]
+	room, url := setupRoom(t, goroom.Options[string]{
+		OnMessage: func(player string, message []byte) {
+			room.SendMessageToAllPlayers(message)
+		},
+	})
+	c := dial(t, url, "alice", client.Options{})
+
+	if err := c.SendValue(move{X: 1, Y: 2}); err != nil {
+		t.Fatalf("SendValue returned error: %v", err)
+	}
+	var got move
+	if err := c.Decode(receive(t, c), &got); err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if got != (move{X: 1, Y: 2}) {
+		t.Errorf("unexpected echo: %+v", got)
+	}
+}
+
+func TestClient_RTT(t *testing.T) {
+	_, url := setupRoom(t, goroom.Options[string]{})
+	c := dial(t, url, "alice", client.Options{PingInterval: 10 * time.Millisecond})
+	deadline := time.Now().Add(time.Second)
+	for c.RTT() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for a pong")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	t.Run("should reconnect after the connection drops", func(t *testing.T) {
+		var mu sync.Mutex
+		connects := 0
+		room, url := setupRoom(t, goroom.Options[string]{})
+		c := dial(t, url, "alice", client.Options{
+			MinBackoff: time.Millisecond,
+			OnConnect: func() {
+				mu.Lock()
+				connects++
+				mu.Unlock()
+			},
+		})
+		deadline := time.Now().Add(time.Second)
+		for !room.GetPlayerPresence("alice").IsConnected {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for alice to connect")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		room.KickPlayer("alice")
+
+		for {
+			mu.Lock()
+			n := connects
+			mu.Unlock()
+			if n == 2 && room.GetPlayerPresence("alice").IsConnected {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for alice to reconnect, got %d connects", n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		room.SendMessageToPlayer("alice", []byte("welcome back"))
+		if msg := receive(t, c); string(msg) != "welcome back" {
+			t.Errorf("unexpected message: %s", msg)
+		}
+	})
+	t.Run("should stop when the server refuses the player", func(t *testing.T) {
+		room, url := setupRoom(t, goroom.Options[string]{})
+		c := dial(t, url, "alice", client.Options{MinBackoff: time.Millisecond})
+		room.SetStatus(goroom.Inactive)
+		room.KickPlayer("alice")
+		select {
+		case _, ok := <-c.Receive():
+			if ok {
+				t.Fatal("unexpected message")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the client to give up")
+		}
+		var status ws.StatusError
+		if !errors.As(c.Err(), &status) || status != http.StatusForbidden {
+			t.Errorf("expected a 403 status error, got %v", c.Err())
+		}
+		if err := c.Send([]byte("hello")); !errors.Is(err, client.ErrClosed) {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+	t.Run("should close while reconnecting", func(t *testing.T) {
+		var requests atomic.Int32
+		reconnecting := make(chan struct{})
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			first := requests.Add(1) == 1
+			if !first {
+				close(reconnecting)
+				<-release
+			}
+			conn, _, _, err := ws.UpgradeHTTP(r, w)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			if first {
+				// Drop the first connection.
+				return
+			}
+			// Hold the connection open without answering a close frame.
+			_, _ = io.Copy(io.Discard, conn)
+		}))
+		defer srv.Close()
+		c, err := client.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), client.Options{MinBackoff: time.Millisecond})
+		if err != nil {
+			t.Fatalf("Dial returned error: %v", err)
+		}
+		select {
+		case <-reconnecting:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the reconnection")
+		}
+
+		closed := make(chan struct{})
+		go func() {
+			c.Close()
+			close(closed)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("Close did not return")
+		}
+	})
+	t.Run("should send the resume token when reconnecting", func(t *testing.T) {
+		tokens := make(chan string, 2)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokens <- r.Header.Get(client.ResumeTokenHeader)
+			upgrader := ws.HTTPUpgrader{Header: http.Header{client.ResumeTokenHeader: {"token-1"}}}
+			conn, _, _, err := upgrader.Upgrade(r, w)
+			if err != nil {
+				return
+			}
+			if len(tokens) == 1 {
+				// Drop the first connection.
+				conn.Close()
+				return
+			}
+			defer conn.Close()
+			for {
+				if _, _, err := wsutil.ReadClientData(conn); err != nil {
+					return
+				}
+			}
+		}))
+		defer srv.Close()
+		c, err := client.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"), client.Options{MinBackoff: time.Millisecond})
+		if err != nil {
+			t.Fatalf("Dial returned error: %v", err)
+		}
+		defer c.Close()
+		for i, want := range []string{"", "token-1"} {
+			select {
+			case got := <-tokens:
+				if got != want {
+					t.Errorf("connection %d: expected resume token %q, got %q", i, want, got)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for connection %d", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,446 @@
+package goroom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	clusterQueueSize               = 1024
+	defaultBrokerHeartbeat         = 5 * time.Second
+	defaultBrokerPublishTimeout    = 5 * time.Second
+	clusterMissedHeartbeatsAllowed = 3
+)
+
+// clusterKind identifies a message between the nodes of a room.
+type clusterKind string
+
+const (
+	// clusterAll is a message for every player.
+	clusterAll clusterKind = "all"
+	// clusterPlayer is a message for one player, delivered by the node they are connected to.
+	clusterPlayer clusterKind = "player"
+	// clusterPresence reports a player connecting, disconnecting or being removed on the sending node.
+	clusterPresence clusterKind = "presence"
+	// clusterState lists every player connected to the sending node, with the room's status as it knows it. It is the
+	// node's heartbeat, and corrects any presence or status the other nodes missed, such as when they start.
+	clusterState clusterKind = "state"
+	// clusterStatus reports the room's status changing.
+	clusterStatus clusterKind = "status"
+	// clusterSync asks the other nodes to send their state, when a node starts.
+	clusterSync clusterKind = "sync"
+	// clusterLeave reports that the sending node's room has stopped and its players are gone.
+	clusterLeave clusterKind = "leave"
+)
+
+type clusterMessage[PlayerID comparable] struct {
+	Node    string      `json:"node"`
+	Kind    clusterKind `json:"kind"`
+	Player  *PlayerID   `json:"player,omitempty"`
+	Players []PlayerID  `json:"players,omitempty"`
+	Payload []byte      `json:"payload,omitempty"`
+	Status  string      `json:"status,omitempty"`
+	// StatusTime is when Status was set, for clusterState.
+	StatusTime time.Time `json:"statusTime,omitzero"`
+	Event      EventType `json:"event,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// remotePresence is a player connected, or last seen, on another node.
+type remotePresence struct {
+	node      string
+	connected bool
+	lastSeen  time.Time
+}
+
+// cluster connects a room to the other nodes hosting it through a Broker. Outgoing messages are queued and
+// published in order on their own goroutine, so that the room never waits on the broker while holding its lock.
+// The same goroutine sends the node's state every heartbeat.
+type cluster[RoomId comparable, PlayerID comparable] struct {
+	room           *Room[RoomId, PlayerID]
+	broker         Broker
+	node           string
+	topic          string
+	heartbeat      time.Duration
+	publishTimeout time.Duration
+	sl             *slog.Logger
+
+	mu     sync.RWMutex
+	remote map[PlayerID]remotePresence
+	// heard is when each other node was last heard from, by this node's clock.
+	heard map[string]time.Time
+	// statusTime is when the room's status was last set on any node, as far as this node knows. The latest wins.
+	statusTime time.Time
+
+	sendMu sync.Mutex
+	closed bool
+	queue  chan clusterMessage[PlayerID]
+	// overflow holds, in order, the control messages that did not fit in the queue, as they are never dropped.
+	overflow    []clusterMessage[PlayerID]
+	resync      chan struct{}
+	published   chan struct{}
+	unsubscribe func()
+	// ctx bounds publishing; abandon cancels it when stop gives up waiting.
+	ctx     context.Context
+	abandon context.CancelFunc
+}
+
+func newNodeID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newCluster[RoomId comparable, PlayerID comparable](room *Room[RoomId, PlayerID], opts Options[PlayerID]) *cluster[RoomId, PlayerID] {
+	c := &cluster[RoomId, PlayerID]{
+		room:           room,
+		broker:         opts.Broker,
+		node:           opts.NodeID,
+		heartbeat:      opts.BrokerHeartbeat,
+		publishTimeout: opts.BrokerPublishTimeout,
+		remote:         make(map[PlayerID]remotePresence),
+		heard:          make(map[string]time.Time),
+		queue:          make(chan clusterMessage[PlayerID], clusterQueueSize),
+		resync:         make(chan struct{}, 1),
+	}
+	if c.node == "" {
+		c.node = newNodeID()
+	}
+	if c.heartbeat == 0 {
+		c.heartbeat = defaultBrokerHeartbeat
+	}
+	if c.publishTimeout == 0 {
+		c.publishTimeout = defaultBrokerPublishTimeout
+	}
+	c.sl = room.Slogger.With("func", "room.cluster", "node", c.node)
+	c.ctx, c.abandon = context.WithCancel(context.Background())
+	return c
+}
+
+// start subscribes to the room's topic, which is fixed from now on, and asks the other nodes for their state.
+func (c *cluster[RoomId, PlayerID]) start() error {
+	c.topic = "goroom.room." + c.room.label()
+	c.sl = c.room.Slogger.With("func", "room.cluster", "node", c.node)
+	unsubscribe, err := c.broker.Subscribe(c.room.ctx, c.topic, c.receive)
+	if err != nil {
+		return fmt.Errorf("subscribe to %s: %w", c.topic, err)
+	}
+	c.unsubscribe = unsubscribe
+	c.published = make(chan struct{})
+	c.send(clusterMessage[PlayerID]{Kind: clusterSync})
+	c.requestResync()
+	go c.publishLoop()
+	return nil
+}
+
+// stop tells the other nodes that this node's players are gone and waits, for at most the publish timeout, for
+// queued messages to be published. Later messages are discarded.
+func (c *cluster[RoomId, PlayerID]) stop() {
+	started := c.unsubscribe != nil
+	if started {
+		c.unsubscribe()
+		c.send(clusterMessage[PlayerID]{Kind: clusterLeave})
+	}
+	c.sendMu.Lock()
+	c.closed = true
+	close(c.queue)
+	c.sendMu.Unlock()
+	if !started {
+		return
+	}
+	timer := time.NewTimer(c.publishTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.published:
+	case <-timer.C:
+		c.sl.Warn("gave up publishing to the broker", "unpublished", len(c.queue))
+		c.abandon()
+	}
+}
+
+func (c *cluster[RoomId, PlayerID]) publishLoop() {
+	defer close(c.published)
+	ticker := c.room.clock.NewTicker(c.heartbeat)
+	defer ticker.Stop()
+	resync := false
+	for {
+		select {
+		case msg, ok := <-c.queue:
+			if !ok {
+				for _, msg := range c.takeOverflow() {
+					c.publish(msg)
+				}
+				return
+			}
+			c.publish(msg)
+			for _, msg := range c.takeOverflow() {
+				c.publish(msg)
+			}
+		case <-ticker.C():
+			resync = true
+			c.expire()
+		case <-c.resync:
+			resync = true
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+		// The state is sent once earlier messages are out, so that they cannot overwrite it.
+		if resync && len(c.queue) == 0 {
+			resync = false
+			c.publish(c.state())
+		}
+	}
+}
+
+func (c *cluster[RoomId, PlayerID]) publish(msg clusterMessage[PlayerID]) {
+	data, err := json.Marshal(msg)
+	if err == nil {
+		// The room's context may already be cancelled while the final messages are published.
+		ctx, cancel := context.WithTimeout(c.ctx, c.publishTimeout)
+		err = c.broker.Publish(ctx, c.topic, data)
+		cancel()
+	}
+	if err != nil {
+		c.sl.Error("publish failed", "kind", msg.Kind, "err", err)
+	}
+}
+
+// send queues a message for the other nodes. If the broker has fallen too far behind, messages for players are
+// dropped, but control messages wait in the overflow so that the other nodes' view of the room stays right.
+func (c *cluster[RoomId, PlayerID]) send(msg clusterMessage[PlayerID]) {
+	msg.Node = c.node
+	if msg.Time.IsZero() {
+		msg.Time = c.room.clock.Now()
+	}
+	control := msg.Kind != clusterAll && msg.Kind != clusterPlayer
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return
+	}
+	if control && len(c.overflow) > 0 {
+		c.overflow = append(c.overflow, msg)
+		return
+	}
+	select {
+	case c.queue <- msg:
+	default:
+		if control {
+			c.overflow = append(c.overflow, msg)
+			return
+		}
+		c.sl.Warn("cluster queue full, dropping message", "kind", msg.Kind)
+	}
+}
+
+// takeOverflow returns the overflowing control messages once the queue ahead of them has been published.
+func (c *cluster[RoomId, PlayerID]) takeOverflow() []clusterMessage[PlayerID] {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if len(c.queue) > 0 {
+		return nil
+	}
+	overflow := c.overflow
+	c.overflow = nil
+	return overflow
+}
+
+func (c *cluster[RoomId, PlayerID]) requestResync() {
+	select {
+	case c.resync <- struct{}{}:
+	default:
+	}
+}
+
+func (c *cluster[RoomId, PlayerID]) sendAll(message []byte) {
+	c.send(clusterMessage[PlayerID]{Kind: clusterAll, Payload: message})
+}
+
+func (c *cluster[RoomId, PlayerID]) sendPlayer(player PlayerID, message []byte) {
+	c.send(clusterMessage[PlayerID]{Kind: clusterPlayer, Player: &player, Payload: message})
+}
+
+func (c *cluster[RoomId, PlayerID]) sendPresence(event EventType, player PlayerID) {
+	c.send(clusterMessage[PlayerID]{Kind: clusterPresence, Player: &player, Event: event})
+}
+
+func (c *cluster[RoomId, PlayerID]) sendStatus(status RoomStatus) {
+	now := c.room.clock.Now()
+	c.mu.Lock()
+	c.statusTime = now
+	c.mu.Unlock()
+	c.send(clusterMessage[PlayerID]{Kind: clusterStatus, Status: status.String(), Time: now})
+}
+
+// state lists the players connected to this node and the room's status.
+func (c *cluster[RoomId, PlayerID]) state() clusterMessage[PlayerID] {
+	sessions, _ := c.room.connectedSessions()
+	players := make([]PlayerID, 0, len(sessions))
+	for _, ss := range sessions {
+		players = append(players, ss.ReferenceID())
+	}
+	c.room.mu.RLock()
+	status := c.room.Status
+	c.room.mu.RUnlock()
+	c.mu.RLock()
+	statusTime := c.statusTime
+	c.mu.RUnlock()
+	return clusterMessage[PlayerID]{
+		Node:       c.node,
+		Kind:       clusterState,
+		Players:    players,
+		Status:     status.String(),
+		StatusTime: statusTime,
+		Time:       c.room.clock.Now(),
+	}
+}
+
+func (c *cluster[RoomId, PlayerID]) receive(data []byte) {
+	var msg clusterMessage[PlayerID]
+	if err := json.Unmarshal(data, &msg); err != nil {
+		c.sl.Warn("invalid cluster message", "err", err)
+		return
+	}
+	if msg.Node == c.node {
+		return
+	}
+	if msg.Kind != clusterLeave && !c.hear(msg.Node) {
+		// A node that is new, or that was presumed gone, needs this node's state.
+		c.requestResync()
+	}
+	room := c.room
+	switch msg.Kind {
+	case clusterAll:
+		room.sendToLocalPlayers(room.ctx, msg.Payload)
+	case clusterPlayer:
+		if msg.Player != nil {
+			room.sendToLocalPlayer(room.ctx, *msg.Player, msg.Payload)
+		}
+	case clusterPresence:
+		if msg.Player != nil {
+			c.updatePresence(msg.Node, msg.Event, *msg.Player, msg.Time)
+		}
+	case clusterState:
+		c.updateState(msg.Node, msg.Players, msg.Time)
+		if !msg.StatusTime.IsZero() {
+			c.updateStatus(msg.Status, msg.StatusTime)
+		}
+	case clusterStatus:
+		c.updateStatus(msg.Status, msg.Time)
+	case clusterSync:
+		c.requestResync()
+	case clusterLeave:
+		c.mu.Lock()
+		c.forget(msg.Node, msg.Time)
+		c.mu.Unlock()
+	}
+}
+
+// hear records that the node is alive, reporting whether it was already known.
+func (c *cluster[RoomId, PlayerID]) hear(node string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, known := c.heard[node]
+	c.heard[node] = c.room.clock.Now()
+	return known
+}
+
+// expire presumes that nodes silent for several heartbeats have gone, disconnecting their players.
+func (c *cluster[RoomId, PlayerID]) expire() {
+	now := c.room.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for node, heard := range c.heard {
+		if now.Sub(heard) > clusterMissedHeartbeatsAllowed*c.heartbeat {
+			c.sl.Warn("node presumed gone", "peer", node, "lastHeard", heard)
+			c.forget(node, heard)
+		}
+	}
+}
+
+// forget marks the node's players disconnected. The caller must hold c.mu.
+func (c *cluster[RoomId, PlayerID]) forget(node string, at time.Time) {
+	delete(c.heard, node)
+	for player, p := range c.remote {
+		if p.node == node && p.connected {
+			c.remote[player] = remotePresence{node: node, lastSeen: at}
+		}
+	}
+}
+
+func (c *cluster[RoomId, PlayerID]) updatePresence(node string, event EventType, player PlayerID, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch event {
+	case EventPlayerConnected:
+		c.remote[player] = remotePresence{node: node, connected: true, lastSeen: at}
+	case EventPlayerDisconnected:
+		// Ignore a stale disconnect from a node the player has since left.
+		if p, ok := c.remote[player]; !ok || p.node == node {
+			c.remote[player] = remotePresence{node: node, lastSeen: at}
+		}
+	case EventPlayerRemoved:
+		if p, ok := c.remote[player]; ok && p.node == node {
+			delete(c.remote, player)
+		}
+	}
+}
+
+// updateState replaces what is known of the players connected to the node with its state.
+func (c *cluster[RoomId, PlayerID]) updateState(node string, players []PlayerID, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	listed := make(map[PlayerID]struct{}, len(players))
+	for _, player := range players {
+		listed[player] = struct{}{}
+		c.remote[player] = remotePresence{node: node, connected: true, lastSeen: at}
+	}
+	for player, p := range c.remote {
+		if _, ok := listed[player]; !ok && p.node == node && p.connected {
+			c.remote[player] = remotePresence{node: node, lastSeen: at}
+		}
+	}
+}
+
+// updateStatus sets the room's status unless this node knows of a later one.
+func (c *cluster[RoomId, PlayerID]) updateStatus(text string, at time.Time) {
+	status, err := ParseRoomStatus(text)
+	if err != nil {
+		c.sl.Warn("invalid cluster status", "err", err)
+		return
+	}
+	c.mu.Lock()
+	if !at.After(c.statusTime) {
+		c.mu.Unlock()
+		return
+	}
+	c.statusTime = at
+	c.mu.Unlock()
+	c.room.setStatus(status)
+}
+
+// presence returns the player's presence on other nodes, if any.
+func (c *cluster[RoomId, PlayerID]) presence(player PlayerID) (PlayerPresence[PlayerID], bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	p, ok := c.remote[player]
+	return PlayerPresence[PlayerID]{ID: player, IsConnected: p.connected, LastSeen: p.lastSeen}, ok
+}
+
+// presences returns the presence of every player known on other nodes.
+func (c *cluster[RoomId, PlayerID]) presences() []PlayerPresence[PlayerID] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]PlayerPresence[PlayerID], 0, len(c.remote))
+	for player, p := range c.remote {
+		out = append(out, PlayerPresence[PlayerID]{ID: player, IsConnected: p.connected, LastSeen: p.lastSeen})
+	}
+	return out
+}
@@ -0,0 +1,280 @@
+package goroom
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// partitionedBroker drops everything to and from one node while it is cut off.
+type partitionedBroker struct {
+	Broker
+	cut atomic.Bool
+}
+
+func (b *partitionedBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	if b.cut.Load() {
+		return nil
+	}
+	return b.Broker.Publish(ctx, topic, payload)
+}
+
+func (b *partitionedBroker) Subscribe(ctx context.Context, topic string, handle func(payload []byte)) (func(), error) {
+	return b.Broker.Subscribe(ctx, topic, func(payload []byte) {
+		if !b.cut.Load() {
+			handle(payload)
+		}
+	})
+}
+
+// stalledBroker never completes a publish.
+type stalledBroker struct {
+	Broker
+}
+
+func (stalledBroker) Publish(ctx context.Context, _ string, _ []byte) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func clusterRoom(t *testing.T, broker Broker, node string) *Room[string, string] {
+	t.Helper()
+	return clusterRoomWith(t, Options[string]{Broker: broker, NodeID: node})
+}
+
+func clusterRoomWith(t *testing.T, opts Options[string]) *Room[string, string] {
+	t.Helper()
+	opts.IgnoreCleanup = true
+	room, err := NewRoom[string, string](context.Background(), "lobby", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	t.Cleanup(room.Stop)
+	return room
+}
+
+// eventually polls cond until it holds or a second has passed.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func roomStatus(room *Room[string, string]) RoomStatus {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.Status
+}
+
+func TestRoom_Broker(t *testing.T) {
+	broker := NewMemoryBroker()
+	a := clusterRoom(t, broker, "a")
+	b := clusterRoom(t, broker, "b")
+
+	alice, err := a.ConnectMemory(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := b.ConnectMemory(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should share presence", func(t *testing.T) {
+		eventually(t, "bob to be present on a", func() bool { return a.GetPlayerPresence("bob").IsConnected })
+		eventually(t, "alice to be present on b", func() bool { return b.GetPlayerPresence("alice").IsConnected })
+		if got := len(a.GetPlayerPresences()); got != 2 {
+			t.Errorf("expected 2 players on a, got %d", got)
+		}
+		if a.CanJoin("bob") {
+			t.Error("expected a to refuse bob, who is connected to b")
+		}
+	})
+	t.Run("should send to every player", func(t *testing.T) {
+		a.SendMessageToAllPlayers([]byte("hello all"))
+		expectReceived(t, alice, "hello all")
+		expectReceived(t, bob, "hello all")
+	})
+	t.Run("should send to a player on another node", func(t *testing.T) {
+		a.SendMessageToPlayer("bob", []byte("hello bob"))
+		expectReceived(t, bob, "hello bob")
+		select {
+		case msg := <-alice.Receive():
+			t.Errorf("expected alice to receive nothing, got '%s'", msg)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+	t.Run("should share status", func(t *testing.T) {
+		a.SetStatus(Locked)
+		eventually(t, "b to be locked", func() bool { return roomStatus(b) == Locked })
+		if b.CanJoin("carol") {
+			t.Error("expected the locked room to refuse a new player")
+		}
+	})
+	t.Run("should share disconnects", func(t *testing.T) {
+		bob.Close()
+		eventually(t, "bob to be disconnected on a", func() bool { return !a.GetPlayerPresence("bob").IsConnected })
+		if !b.CanJoin("bob") {
+			t.Error("expected bob to be able to rejoin the locked room")
+		}
+	})
+}
+
+func TestRoom_Broker_Stop(t *testing.T) {
+	broker := NewMemoryBroker()
+	a := clusterRoom(t, broker, "a")
+	b := clusterRoom(t, broker, "b")
+	if _, err := b.ConnectMemory(context.Background(), "bob"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "bob to be present on a", func() bool { return a.GetPlayerPresence("bob").IsConnected })
+
+	b.Stop()
+	eventually(t, "bob to be disconnected on a", func() bool { return !a.GetPlayerPresence("bob").IsConnected })
+	if !a.CanJoin("bob") {
+		t.Error("expected bob to be able to join a once b has stopped")
+	}
+}
+
+func TestRoom_Broker_LateNode(t *testing.T) {
+	broker := NewMemoryBroker()
+	a := clusterRoom(t, broker, "a")
+	eventually(t, "a to start", func() bool {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		return a.isStarted
+	})
+	a.SetStatus(Locked)
+
+	b := clusterRoom(t, broker, "b")
+	eventually(t, "b to be locked", func() bool { return roomStatus(b) == Locked })
+	if b.CanJoin("carol") {
+		t.Error("expected a node started after the room was locked to refuse a new player")
+	}
+
+	// b's default status, which is older than the lock, must not reopen the room on a.
+	time.Sleep(20 * time.Millisecond)
+	if got := roomStatus(a); got != Locked {
+		t.Errorf("expected a to stay locked, got %v", got)
+	}
+}
+
+func TestRoom_Broker_Drain(t *testing.T) {
+	broker := NewMemoryBroker()
+	a := clusterRoomWith(t, Options[string]{Broker: broker, NodeID: "a", DrainMessage: []byte("going away")})
+	b := clusterRoom(t, broker, "b")
+	alice, err := a.ConnectMemory(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := b.ConnectMemory(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "bob to be present on a", func() bool { return a.GetPlayerPresence("bob").IsConnected })
+
+	go func() {
+		<-alice.Receive()
+		alice.Close()
+	}()
+	if err := a.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	select {
+	case msg := <-bob.Receive():
+		t.Errorf("expected bob on another node to receive nothing, got '%s'", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestRoom_Broker_Partition(t *testing.T) {
+	broker := NewMemoryBroker()
+	partitioned := &partitionedBroker{Broker: broker}
+	a := clusterRoomWith(t, Options[string]{Broker: broker, NodeID: "a", BrokerHeartbeat: 10 * time.Millisecond})
+	b := clusterRoomWith(t, Options[string]{Broker: partitioned, NodeID: "b", BrokerHeartbeat: 10 * time.Millisecond})
+	if _, err := b.ConnectMemory(context.Background(), "bob"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "bob to be present on a", func() bool { return a.GetPlayerPresence("bob").IsConnected })
+
+	t.Run("should expire a silent node", func(t *testing.T) {
+		partitioned.cut.Store(true)
+		eventually(t, "bob to be disconnected on a", func() bool { return !a.GetPlayerPresence("bob").IsConnected })
+		if !a.CanJoin("bob") {
+			t.Error("expected bob to be able to join a once b has gone silent")
+		}
+	})
+	t.Run("should restore a node that is heard again", func(t *testing.T) {
+		partitioned.cut.Store(false)
+		eventually(t, "bob to be present on a", func() bool { return a.GetPlayerPresence("bob").IsConnected })
+	})
+}
+
+func TestRoom_Broker_StalledStop(t *testing.T) {
+	room := clusterRoomWith(t, Options[string]{
+		Broker:               stalledBroker{Broker: NewMemoryBroker()},
+		NodeID:               "a",
+		BrokerPublishTimeout: 20 * time.Millisecond,
+	})
+	eventually(t, "the room to start", func() bool {
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		return room.isStarted
+	})
+	if _, err := room.ConnectMemory(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	room.SendMessageToAllPlayers([]byte("hello"))
+
+	room.Stop()
+	select {
+	case <-room.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the room to stop with a stalled broker")
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	broker := NewMemoryBroker()
+	got := make(chan string, 10)
+	unsubscribe, err := broker.Subscribe(context.Background(), "topic", func(payload []byte) { got <- string(payload) })
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three"} {
+		if err := broker.Publish(context.Background(), "topic", []byte(msg)); err != nil {
+			t.Fatalf("Publish returned error: %v", err)
+		}
+	}
+	if err := broker.Publish(context.Background(), "other", []byte("ignored")); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	for _, want := range []string{"one", "two", "three"} {
+		select {
+		case msg := <-got:
+			if msg != want {
+				t.Fatalf("expected '%s', got '%s'", want, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for '%s'", want)
+		}
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if err := broker.Publish(context.Background(), "topic", []byte("late")); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	select {
+	case msg := <-got:
+		t.Errorf("expected nothing after unsubscribing, got '%s'", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
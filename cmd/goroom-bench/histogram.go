@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// histogram records durations into logarithmic buckets, each 5% wider than the last, so percentiles are accurate
+// to within 5% without keeping every sample. It is safe for concurrent use.
+type histogram struct {
+	buckets [histogramBuckets]atomic.Uint64
+	count   atomic.Uint64
+	max     atomic.Int64
+}
+
+const (
+	histogramGrowth  = 1.05
+	histogramBuckets = 400 // 1µs * 1.05^400 is over 80 hours
+)
+
+var logGrowth = math.Log(histogramGrowth)
+
+func (h *histogram) record(d time.Duration) {
+	h.buckets[bucketFor(d)].Add(1)
+	h.count.Add(1)
+	for {
+		m := h.max.Load()
+		if int64(d) <= m || h.max.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+func bucketFor(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	return min(int(math.Ceil(math.Log(us)/logGrowth)), histogramBuckets-1)
+}
+
+// upperBound is the largest duration recorded in bucket i.
+func upperBound(i int) time.Duration {
+	return time.Duration(math.Pow(histogramGrowth, float64(i)) * float64(time.Microsecond))
+}
+
+// percentile returns the upper bound of the bucket holding the p'th percentile, where p is between 0 and 100.
+func (h *histogram) percentile(p float64) time.Duration {
+	total := h.count.Load()
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(total)))
+	var seen uint64
+	for i := range h.buckets {
+		seen += h.buckets[i].Load()
+		if seen >= rank && seen > 0 {
+			return min(upperBound(i), time.Duration(h.max.Load()))
+		}
+	}
+	return time.Duration(h.max.Load())
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	var h histogram
+	if h.percentile(50) != 0 {
+		t.Error("expected zero for an empty histogram")
+	}
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	for _, tt := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	} {
+		got := h.percentile(tt.p)
+		if got < tt.want || float64(got) > float64(tt.want)*histogramGrowth {
+			t.Errorf("p%v: expected %v within 5%%, got %v", tt.p, tt.want, got)
+		}
+	}
+}
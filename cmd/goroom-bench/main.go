@@ -0,0 +1,346 @@
+// Command goroom-bench measures how a room behaves as players are added. It connects simulated players to a room,
+// has them send messages that the room broadcasts to everyone, and reports connect times, broadcast fanout latency,
+// dropped messages and, for a local room, server memory.
+//
+// With no -url it starts a local room that broadcasts every message it receives:
+//
+//	goroom-bench -players 1000 -ramp 10s -rate 50 -duration 30s
+//
+// To target a running server, the room must broadcast each message to every player unchanged. Each player's ID is
+// added to the URL as the -id-param query parameter:
+//
+//	goroom-bench -url ws://localhost:10101/chat -id-param username -players 200
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chilledoj/goroom"
+	"github.com/chilledoj/goroom/client"
+)
+
+type config struct {
+	url      string
+	idParam  string
+	players  int
+	ramp     time.Duration
+	rate     float64
+	size     int
+	duration time.Duration
+	settle   time.Duration
+	buffer   int
+}
+
+func main() {
+	var cfg config
+	flag.StringVar(&cfg.url, "url", "", "websocket URL of the room; empty starts a local room")
+	flag.StringVar(&cfg.idParam, "id-param", "player", "query parameter carrying each player's ID")
+	flag.IntVar(&cfg.players, "players", 100, "number of simulated players")
+	flag.DurationVar(&cfg.ramp, "ramp", 5*time.Second, "time over which players connect")
+	flag.Float64Var(&cfg.rate, "rate", 10, "messages per second sent across all players")
+	flag.IntVar(&cfg.size, "size", 64, "message size in bytes (at least 8)")
+	flag.DurationVar(&cfg.duration, "duration", 10*time.Second, "how long to send for once every player has connected")
+	flag.DurationVar(&cfg.settle, "settle", time.Second, "how long to wait for in-flight messages after sending stops")
+	flag.IntVar(&cfg.buffer, "buffer", 1024, "receive buffer per player")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := run(ctx, cfg, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "goroom-bench:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg config, out io.Writer) error {
+	if cfg.players <= 0 {
+		return errors.New("players must be positive")
+	}
+	if cfg.rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	if cfg.rate > float64(time.Second) {
+		// The send ticker's interval would round down to zero.
+		return fmt.Errorf("rate must be at most %d", time.Second)
+	}
+	cfg.size = max(cfg.size, 8)
+
+	var server *localServer
+	if cfg.url == "" {
+		var err error
+		server, err = startLocalServer(ctx, cfg.idParam)
+		if err != nil {
+			return err
+		}
+		defer server.close()
+		cfg.url = server.url
+		fmt.Fprintln(out, "started local room at", cfg.url)
+	}
+
+	b := &bench{cfg: cfg}
+	b.connectAll(ctx)
+	defer b.closeAll()
+	if len(b.clients) == 0 {
+		return errors.New("no players connected")
+	}
+
+	b.sendFor(ctx, cfg.duration)
+	select {
+	case <-time.After(cfg.settle):
+	case <-ctx.Done():
+	}
+
+	b.report(out, server)
+	return nil
+}
+
+// bench holds the simulated players and what they have measured.
+type bench struct {
+	cfg config
+
+	mu      sync.Mutex
+	clients []*client.Client
+	failed  int
+	connect []time.Duration
+
+	readers  sync.WaitGroup
+	sent     atomic.Uint64
+	expected atomic.Uint64
+	received atomic.Uint64
+	fanout   histogram
+}
+
+// connectAll connects the players one at a time, spread evenly across the ramp.
+func (b *bench) connectAll(ctx context.Context) {
+	interval := b.cfg.ramp / time.Duration(b.cfg.players)
+	var wg sync.WaitGroup
+	for i := range b.cfg.players {
+		if i > 0 && interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.connectOne(ctx, fmt.Sprintf("bench-%d", i))
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *bench) connectOne(ctx context.Context, id string) {
+	u, err := url.Parse(b.cfg.url)
+	if err != nil {
+		b.mu.Lock()
+		b.failed++
+		b.mu.Unlock()
+		return
+	}
+	q := u.Query()
+	q.Set(b.cfg.idParam, id)
+	u.RawQuery = q.Encode()
+
+	start := time.Now()
+	c, err := client.Dial(ctx, u.String(), client.Options{
+		DisableReconnect: true,
+		Buffer:           b.cfg.buffer,
+		Slogger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	elapsed := time.Since(start)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.failed++
+		return
+	}
+	b.clients = append(b.clients, c)
+	b.connect = append(b.connect, elapsed)
+	b.readers.Add(1)
+	go b.read(c)
+}
+
+// read records the fanout latency of every message a player receives from the bench.
+func (b *bench) read(c *client.Client) {
+	defer b.readers.Done()
+	for msg := range c.Receive() {
+		if len(msg) < 8 {
+			continue
+		}
+		sent := int64(binary.BigEndian.Uint64(msg))
+		b.received.Add(1)
+		b.fanout.record(time.Duration(time.Now().UnixNano() - sent))
+	}
+}
+
+// sendFor sends messages from randomly chosen players at the configured rate. Every message carries the time it was
+// sent so that each receiver can measure how long the broadcast took to reach it.
+func (b *bench) sendFor(ctx context.Context, d time.Duration) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / b.cfg.rate))
+	defer ticker.Stop()
+	deadline := time.After(d)
+
+	b.mu.Lock()
+	clients := slices.Clone(b.clients)
+	b.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			return
+		case <-ticker.C:
+			msg := make([]byte, b.cfg.size)
+			binary.BigEndian.PutUint64(msg, uint64(time.Now().UnixNano()))
+			if err := clients[rand.IntN(len(clients))].Send(msg); err != nil {
+				continue
+			}
+			b.sent.Add(1)
+			b.expected.Add(uint64(connected(clients)))
+		}
+	}
+}
+
+func connected(clients []*client.Client) int {
+	n := 0
+	for _, c := range clients {
+		if c.Connected() {
+			n++
+		}
+	}
+	return n
+}
+
+func (b *bench) closeAll() {
+	for _, c := range b.clients {
+		_ = c.Close()
+	}
+	b.readers.Wait()
+}
+
+func (b *bench) report(out io.Writer, server *localServer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fmt.Fprintf(out, "players          %d connected, %d failed\n", len(b.clients), b.failed)
+
+	var connect histogram
+	for _, d := range b.connect {
+		connect.record(d)
+	}
+	fmt.Fprintf(out, "connect          %s\n", percentiles(&connect))
+
+	sent, expected, received := b.sent.Load(), b.expected.Load(), b.received.Load()
+	dropped := uint64(0)
+	if expected > received {
+		dropped = expected - received
+	}
+	fmt.Fprintf(out, "messages         %d sent, %d deliveries expected, %d received\n", sent, expected, received)
+	fmt.Fprintf(out, "dropped          %d (%.2f%%)\n", dropped, ratio(dropped, expected))
+	fmt.Fprintf(out, "fanout latency   %s\n", percentiles(&b.fanout))
+
+	if server != nil {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		fmt.Fprintf(out, "server dropped   %d\n", server.metrics.dropped.Load())
+		fmt.Fprintf(out, "server memory    heap %s, sys %s, %d goroutines\n",
+			bytesize(ms.HeapAlloc), bytesize(ms.Sys), runtime.NumGoroutine())
+	}
+}
+
+func percentiles(h *histogram) string {
+	return fmt.Sprintf("p50 %v  p90 %v  p99 %v  max %v",
+		h.percentile(50), h.percentile(90), h.percentile(99), h.percentile(100))
+}
+
+func ratio(part, whole uint64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+func bytesize(n uint64) string {
+	return fmt.Sprintf("%.1fMiB", float64(n)/(1<<20))
+}
+
+// localServer is a room that broadcasts every message it receives, served over HTTP on a loopback port. The
+// benchmark shares its process, so its memory figures include the simulated players.
+type localServer struct {
+	url     string
+	room    *goroom.Room[string, string]
+	srv     *http.Server
+	metrics *dropCounter
+}
+
+// dropCounter counts messages the room dropped because a player's send buffer was full.
+type dropCounter struct {
+	goroom.NopMetrics
+	dropped atomic.Uint64
+}
+
+func (d *dropCounter) SendDropped(string) { d.dropped.Add(1) }
+
+func startLocalServer(ctx context.Context, idParam string) (*localServer, error) {
+	ls := &localServer{metrics: &dropCounter{}}
+	room, err := goroom.NewRoom[string, string](ctx, "bench", goroom.Options[string]{
+		OnMessage: func(player string, message []byte) {
+			ls.room.SendMessageToAllPlayers(message)
+		},
+		Metrics: ls.metrics,
+		// Sessions log every connection closed at the end of the run, which would bury the report.
+		Slogger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ls.room = room
+	go room.Start()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		room.Stop()
+		return nil, err
+	}
+	ls.srv = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get(idParam)
+		if id == "" {
+			http.Error(w, idParam+" is required", http.StatusBadRequest)
+			return
+		}
+		room.HandleSocketWithPlayer(id, func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		})(w, r)
+	})}
+	go ls.srv.Serve(ln)
+	ls.url = "ws://" + ln.Addr().String() + "/"
+	return ls, nil
+}
+
+func (ls *localServer) close() {
+	ls.room.Stop()
+	_ = ls.srv.Close()
+}
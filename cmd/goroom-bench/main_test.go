@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestRun_InvalidConfig(t *testing.T) {
+	for _, cfg := range []config{
+		{players: 0, rate: 10},
+		{players: 1, rate: 0},
+		{players: 1, rate: 2e9},
+	} {
+		if err := run(context.Background(), cfg, io.Discard); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type commandKind int
+
+const (
+	cmdNone commandKind = iota
+	cmdSend
+	cmdSleep
+	cmdRTT
+	cmdQuit
+)
+
+// command is one line typed at the prompt or read from a script.
+type command struct {
+	kind    commandKind
+	payload []byte
+	delay   time.Duration
+}
+
+// parseCommand reads a line of input. Lines are sent as text unless they start with a slash:
+//
+//	/hex <bytes>     send binary, written as hex with optional spaces
+//	/sleep <dur>     wait, for pacing scripts
+//	/rtt             print the last measured round trip time
+//	/quit            close the connection and exit
+//	//text           send text that starts with a slash
+//
+// Blank lines and lines starting with # are ignored.
+func parseCommand(line string) (command, error) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return command{kind: cmdNone}, nil
+	}
+	if strings.HasPrefix(line, "//") {
+		return command{kind: cmdSend, payload: []byte(line[1:])}, nil
+	}
+	if !strings.HasPrefix(line, "/") {
+		return command{kind: cmdSend, payload: []byte(line)}, nil
+	}
+
+	name, arg, _ := strings.Cut(line[1:], " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "hex":
+		b, err := hex.DecodeString(strings.ReplaceAll(arg, " ", ""))
+		if err != nil {
+			return command{}, fmt.Errorf("/hex: %w", err)
+		}
+		return command{kind: cmdSend, payload: b}, nil
+	case "sleep":
+		d, err := time.ParseDuration(arg)
+		if err != nil {
+			return command{}, fmt.Errorf("/sleep: %w", err)
+		}
+		return command{kind: cmdSleep, delay: d}, nil
+	case "rtt":
+		return command{kind: cmdRTT}, nil
+	case "quit":
+		return command{kind: cmdQuit}, nil
+	}
+	return command{}, fmt.Errorf("unknown command /%s", name)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// format renders a frame for the terminal: JSON is indented, other text is printed as is and anything that is not
+// valid UTF-8 is hex-dumped.
+func format(msg []byte) string {
+	if json.Valid(msg) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, msg, "", "  "); err == nil {
+			return buf.String()
+		}
+	}
+	if utf8.Valid(msg) {
+		return string(msg)
+	}
+	return strings.TrimSuffix(hex.Dump(msg), "\n")
+}
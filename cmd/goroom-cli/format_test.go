@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+		want string
+	}{
+		{"json", []byte(`{"a":1,"b":[true]}`), "{\n  \"a\": 1,\n  \"b\": [\n    true\n  ]\n}"},
+		{"text", []byte("hello"), "hello"},
+		{"binary", []byte{0xff, 0x00, 'a'}, "00000000  ff 00 61                                          |..a|"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format(tt.msg); got != tt.want {
+				t.Errorf("expected\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    command
+		wantErr bool
+	}{
+		{"hello world", command{kind: cmdSend, payload: []byte("hello world")}, false},
+		{"//literal", command{kind: cmdSend, payload: []byte("/literal")}, false},
+		{"/hex ff00 61", command{kind: cmdSend, payload: []byte{0xff, 0x00, 0x61}}, false},
+		{"/hex zz", command{}, true},
+		{"/sleep 250ms", command{kind: cmdSleep, delay: 250_000_000}, false},
+		{"/sleep soon", command{}, true},
+		{"/rtt", command{kind: cmdRTT}, false},
+		{"/quit", command{kind: cmdQuit}, false},
+		{"# a comment", command{kind: cmdNone}, false},
+		{"", command{kind: cmdNone}, false},
+		{"/nope", command{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := parseCommand(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.kind != tt.want.kind || got.delay != tt.want.delay || !bytes.Equal(got.payload, tt.want.payload) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Command goroom-cli is an interactive client for debugging a room. It prints every frame the room sends, pretty
+// printing JSON and hex-dumping binary, and sends each line typed at the prompt. See parseCommand for the commands
+// it understands.
+//
+//	goroom-cli -url ws://localhost:10101/chat?username=alice
+//
+// A script of the same lines can be replayed to reproduce a bug report, optionally exiting when it finishes:
+//
+//	goroom-cli -url ws://localhost:10101/chat?username=alice -script repro.txt -exit
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chilledoj/goroom/client"
+)
+
+type config struct {
+	url       string
+	header    http.Header
+	script    string
+	exit      bool
+	ping      time.Duration
+	showRTT   bool
+	reconnect bool
+}
+
+// headerFlag collects repeated -H "Name: value" flags.
+type headerFlag http.Header
+
+func (h headerFlag) String() string { return "" }
+
+func (h headerFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok {
+		return fmt.Errorf("header %q must be in the form Name: value", v)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+func main() {
+	cfg := config{header: http.Header{}}
+	flag.StringVar(&cfg.url, "url", "", "websocket URL of the room (required)")
+	flag.Var(headerFlag(cfg.header), "H", "header to send when connecting, as \"Name: value\"; may be repeated")
+	flag.StringVar(&cfg.script, "script", "", "file of lines to send before reading from stdin")
+	flag.BoolVar(&cfg.exit, "exit", false, "exit once the script has finished rather than reading stdin")
+	flag.DurationVar(&cfg.ping, "ping", 5*time.Second, "how often to ping the room to measure RTT")
+	flag.BoolVar(&cfg.showRTT, "show-rtt", false, "print the RTT after every ping")
+	flag.BoolVar(&cfg.reconnect, "reconnect", true, "reconnect when the connection drops")
+	flag.Parse()
+	if cfg.url == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := run(ctx, cfg, os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "goroom-cli:", err)
+		os.Exit(1)
+	}
+}
+
+// terminal serialises output from the receive loop and the prompt.
+type terminal struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (t *terminal) printf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.out, format, args...)
+}
+
+func run(ctx context.Context, cfg config, in io.Reader, out io.Writer) error {
+	term := &terminal{out: out}
+	c, err := client.Dial(ctx, cfg.url, client.Options{
+		Header:           cfg.header,
+		DisableReconnect: !cfg.reconnect,
+		PingInterval:     cfg.ping,
+		OnConnect:        func() { term.printf("* connected to %s\n", cfg.url) },
+		OnDisconnect:     func(err error) { term.printf("* disconnected: %v\n", err) },
+		Slogger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return err
+	}
+
+	received := make(chan struct{})
+	go func() {
+		defer close(received)
+		for msg := range c.Receive() {
+			term.printf("< %s %d bytes\n%s\n", time.Now().Format("15:04:05.000"), len(msg), format(msg))
+		}
+	}()
+	defer func() {
+		c.Close()
+		<-received
+	}()
+
+	if cfg.showRTT {
+		go func() {
+			ticker := time.NewTicker(cfg.ping)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					term.printf("* rtt %v\n", c.RTT())
+				case <-received:
+					return
+				}
+			}
+		}()
+	}
+
+	s := &shell{client: c, term: term}
+	if cfg.script != "" {
+		f, err := os.Open(cfg.script)
+		if err != nil {
+			return err
+		}
+		quit, err := s.runLines(ctx, f, received, true)
+		f.Close()
+		if err != nil || quit || cfg.exit {
+			return err
+		}
+	}
+	_, err = s.runLines(ctx, in, received, false)
+	return err
+}
+
+// shell executes commands against the client.
+type shell struct {
+	client *client.Client
+	term   *terminal
+}
+
+// runLines executes each line from r until it is exhausted, the user quits, the context is cancelled or the client
+// closes. Errors in a script stop it; errors typed at the prompt are printed and the prompt carries on.
+func (s *shell) runLines(ctx context.Context, r io.Reader, closed <-chan struct{}, script bool) (quit bool, err error) {
+	lines := make(chan string)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-ctx.Done():
+				return
+			case <-closed:
+				return
+			}
+		}
+		scanErr <- sc.Err()
+	}()
+
+	for n := 1; ; n++ {
+		var line string
+		var ok bool
+		select {
+		case line, ok = <-lines:
+			if !ok {
+				select {
+				case err := <-scanErr:
+					return false, err
+				default:
+					return false, nil
+				}
+			}
+		case <-ctx.Done():
+			return true, nil
+		case <-closed:
+			if err := s.client.Err(); err != nil && !errors.Is(err, client.ErrClosed) {
+				return true, err
+			}
+			return true, nil
+		}
+
+		cmd, err := parseCommand(line)
+		if err == nil {
+			quit, err = s.execute(ctx, cmd)
+		}
+		if err != nil {
+			if script {
+				return false, fmt.Errorf("script line %d: %w", n, err)
+			}
+			s.term.printf("! %v\n", err)
+		}
+		if quit {
+			return true, nil
+		}
+	}
+}
+
+func (s *shell) execute(ctx context.Context, cmd command) (quit bool, err error) {
+	switch cmd.kind {
+	case cmdSend:
+		if err := s.client.Send(cmd.payload); err != nil {
+			return false, err
+		}
+		s.term.printf("> %s %d bytes\n", time.Now().Format("15:04:05.000"), len(cmd.payload))
+	case cmdSleep:
+		select {
+		case <-time.After(cmd.delay):
+		case <-ctx.Done():
+		}
+	case cmdRTT:
+		s.term.printf("* rtt %v\n", s.client.RTT())
+	case cmdQuit:
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+)
+
+func TestRun_Script(t *testing.T) {
+	var room *goroom.Room[string, string]
+	room, err := goroom.NewRoom[string, string](context.Background(), "cli", goroom.Options[string]{
+		OnMessage: func(player string, message []byte) {
+			room.SendMessageToPlayer(player, message)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go room.Start()
+	t.Cleanup(room.Stop)
+
+	srv := httptest.NewServer(room.HandleSocketWithPlayer("alice", func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}))
+	t.Cleanup(srv.Close)
+
+	script := filepath.Join(t.TempDir(), "script.txt")
+	lines := "# echo a JSON message\n{\"hello\":\"world\"}\n/hex ff00\n/sleep 200ms\n"
+	if err := os.WriteFile(script, []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cfg := config{url: "ws" + strings.TrimPrefix(srv.URL, "http"), script: script, exit: true, ping: time.Second}
+	if err := run(context.Background(), cfg, strings.NewReader(""), &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	for _, want := range []string{"* connected to", "\"hello\": \"world\"", "00000000  ff 00"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected output to contain %q, got\n%s", want, out.String())
+		}
+	}
+}
+
+func TestRun_DialError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	cfg := config{url: "ws" + strings.TrimPrefix(srv.URL, "http"), exit: true}
+	if err := run(context.Background(), cfg, strings.NewReader(""), &bytes.Buffer{}); err == nil {
+		t.Fatal("expected an error dialling a non-websocket endpoint")
+	}
+}
@@ -0,0 +1,156 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultDrainGracePeriod = 5 * time.Second
+
+// goingAwayCloser is implemented by sessions that can tell the player why the connection is closing, such as the
+// websocket session's 1001 close frame. Other sessions are simply closed.
+type goingAwayCloser interface {
+	closeGoingAway(reason string)
+}
+
+// Drain stops the room gracefully, for example before a deploy. The room stops accepting joins and sends
+// Options.DrainMessage to every connected player. Players still connected after the DrainGracePeriod are
+// disconnected, with a 1001 (going away) close on websockets. Once every player has gone and in-flight callbacks
+// have finished, the room is stopped.
+//
+// If ctx ends first, the room is stopped straight away and Drain returns the context's error.
+func (room *Room[RoomId, PlayerID]) Drain(ctx context.Context) error {
+	sl := room.Slogger.With("func", "room.Drain")
+	room.mu.Lock()
+	room.draining = true
+	connected := room.connectedCount()
+	room.mu.Unlock()
+	sl.Info("draining", "players", connected)
+
+	// Every disconnect publishes an event, so the buffer only needs to hold one per player.
+	events, unsubscribe := room.Subscribe(connected + 1)
+	defer unsubscribe()
+
+	err := room.drain(ctx, events)
+	if err != nil {
+		sl.Warn("drain cut short", "err", err)
+	}
+	room.Stop()
+	return err
+}
+
+func (room *Room[RoomId, PlayerID]) drain(ctx context.Context, events <-chan Event[RoomId, PlayerID]) error {
+	if room.opts.DrainMessage != nil {
+		// Only this node's players are going away.
+		room.sendToLocalPlayers(room.ctx, room.opts.DrainMessage)
+	}
+
+	grace := room.opts.DrainGracePeriod
+	if grace == 0 {
+		grace = defaultDrainGracePeriod
+	}
+	timer := room.clock.NewTimer(grace)
+	left, err := room.awaitEmpty(ctx, events, timer.C())
+	timer.Stop()
+	if err != nil {
+		return err
+	}
+	if !left {
+		sessions, _ := room.connectedSessions()
+		for _, ss := range sessions {
+			if c, ok := ss.(goingAwayCloser); ok {
+				c.closeGoingAway("server going away")
+			} else {
+				ss.Close()
+			}
+		}
+		if _, err := room.awaitEmpty(ctx, events, nil); err != nil {
+			return err
+		}
+	}
+
+	// The room has handled every disconnect, so every callback it will start has been started.
+	select {
+	case <-room.callbacks.wait():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// awaitEmpty waits until no players are connected, reporting false if timeout fires first.
+func (room *Room[RoomId, PlayerID]) awaitEmpty(ctx context.Context, events <-chan Event[RoomId, PlayerID], timeout <-chan time.Time) (bool, error) {
+	for {
+		room.mu.RLock()
+		connected := room.connectedCount()
+		room.mu.RUnlock()
+		if connected == 0 {
+			return true, nil
+		}
+		select {
+		case _, ok := <-events:
+			if !ok {
+				// The room stopped, which closes every session.
+				return true, nil
+			}
+		case <-timeout:
+			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// connectedCount is the number of connected players. The caller must hold room.mu.
+func (room *Room[RoomId, PlayerID]) connectedCount() int {
+	n := 0
+	for _, p := range room.players {
+		if p != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// DrainProgress reports a room finishing its drain during RoomManager.Drain.
+type DrainProgress struct {
+	// Room is the string form of the room's ID.
+	Room string
+	// Err is the room's drain error, if any.
+	Err error
+	// Drained counts the rooms that have finished, out of Total.
+	Drained int
+	Total   int
+}
+
+// Drain drains every registered room concurrently, as Room.Drain, calling progress (if not nil) as each room
+// finishes. The rooms stay registered. It returns the errors of any rooms that did not drain cleanly, which
+// includes ctx's error for rooms cut short by its deadline.
+func (rm *RoomManager[RoomId, PlayerID]) Drain(ctx context.Context, progress func(DrainProgress)) error {
+	rooms := rm.Rooms()
+	results := make(chan DrainProgress)
+	for _, room := range rooms {
+		go func() {
+			err := room.Drain(ctx)
+			if err != nil {
+				err = fmt.Errorf("room %s: %w", room.label(), err)
+			}
+			results <- DrainProgress{Room: room.label(), Err: err}
+		}()
+	}
+
+	var errs []error
+	for i := range rooms {
+		p := <-results
+		p.Drained, p.Total = i+1, len(rooms)
+		if p.Err != nil {
+			errs = append(errs, p.Err)
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return errors.Join(errs...)
+}
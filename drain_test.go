@@ -0,0 +1,169 @@
+package goroom_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+
+	"github.com/chilledoj/goroom"
+	"github.com/chilledoj/goroom/goroomtest"
+)
+
+func TestRoom_Drain(t *testing.T) {
+	t.Run("should warn players and close stragglers with going away", func(t *testing.T) {
+		clock := goroomtest.NewFakeClock(time.Now())
+		room, err := goroom.NewRoom[string, string](context.Background(), "drain", goroom.Options[string]{
+			DrainMessage:     []byte("server going away"),
+			DrainGracePeriod: 5 * time.Second,
+			Clock:            clock,
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		events, unsubscribe := room.Subscribe(0)
+		defer unsubscribe()
+		go room.Start()
+
+		srv := httptest.NewServer(room.HandleSocketWithPlayer("alice", func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		}))
+		t.Cleanup(srv.Close)
+		conn, _, _, err := ws.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http"))
+		if err != nil {
+			t.Fatalf("Dial returned error: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		// The upgrade completes before alice is admitted, so wait for her to be in the room.
+		for e := range events {
+			if e.Type == goroom.EventPlayerConnected {
+				break
+			}
+		}
+		// The room's cleanup ticker and alice's ping ticker.
+		clock.BlockUntil(2)
+
+		drained := make(chan error, 1)
+		go func() { drained <- room.Drain(context.Background()) }()
+
+		msg, _, err := wsutil.ReadServerData(conn)
+		if err != nil {
+			t.Fatalf("failed to read the drain message: %v", err)
+		}
+		if string(msg) != "server going away" {
+			t.Errorf("expected the drain message, got '%s'", msg)
+		}
+		if room.CanJoin("bob") {
+			t.Error("expected the draining room to refuse new players")
+		}
+
+		// The grace period's timer.
+		clock.BlockUntil(3)
+		clock.Advance(5 * time.Second)
+		frame, err := ws.ReadFrame(conn)
+		if err != nil {
+			t.Fatalf("failed to read the close frame: %v", err)
+		}
+		if frame.Header.OpCode != ws.OpClose {
+			t.Fatalf("expected a close frame, got %v", frame.Header.OpCode)
+		}
+		if code, _ := ws.ParseCloseFrameData(frame.Payload); code != ws.StatusGoingAway {
+			t.Errorf("expected close code %d, got %d", ws.StatusGoingAway, code)
+		}
+
+		select {
+		case err := <-drained:
+			if err != nil {
+				t.Fatalf("Drain returned error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for Drain")
+		}
+		if err := room.Start(); !errors.Is(err, goroom.ErrRoomStopped) {
+			t.Errorf("expected the room to be stopped, got %v", err)
+		}
+	})
+	t.Run("should finish early when players leave and wait for callbacks", func(t *testing.T) {
+		var finished atomic.Bool
+		room, err := goroom.NewRoom[string, string](context.Background(), "drain", goroom.Options[string]{
+			OnDisconnect: func(player string) {
+				time.Sleep(50 * time.Millisecond)
+				finished.Store(true)
+			},
+			DrainMessage:     []byte("leave now"),
+			DrainGracePeriod: time.Minute,
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		alice, err := room.ConnectMemory(context.Background(), "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			<-alice.Receive()
+			alice.Close()
+		}()
+
+		if err := room.Drain(context.Background()); err != nil {
+			t.Fatalf("Drain returned error: %v", err)
+		}
+		if !finished.Load() {
+			t.Error("expected Drain to wait for OnDisconnect to finish")
+		}
+	})
+	t.Run("should stop the room when the context ends", func(t *testing.T) {
+		room, err := goroom.NewRoom[string, string](context.Background(), "drain", goroom.Options[string]{DrainGracePeriod: time.Minute})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		if _, err := room.ConnectMemory(context.Background(), "alice"); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := room.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected DeadlineExceeded, got %v", err)
+		}
+		if err := room.Start(); !errors.Is(err, goroom.ErrRoomStopped) {
+			t.Errorf("expected the room to be stopped, got %v", err)
+		}
+	})
+}
+
+func TestRoomManager_Drain(t *testing.T) {
+	rm := goroom.NewRoomManager[string, string]()
+	for _, id := range []string{"a", "b"} {
+		room, err := goroom.NewRoom[string, string](context.Background(), id, goroom.Options[string]{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		go room.Start()
+		if err := rm.Add(room); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var reports []goroom.DrainProgress
+	if err := rm.Drain(context.Background(), func(p goroom.DrainProgress) { reports = append(reports, p) }); err != nil {
+		t.Fatalf("Drain returned error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 progress reports, got %+v", reports)
+	}
+	for i, p := range reports {
+		if p.Drained != i+1 || p.Total != 2 || p.Err != nil {
+			t.Errorf("unexpected progress report %+v", p)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package goroom
+
+import "encoding/json"
+
+// ErrorReply is the payload sent by DefaultErrorReply when a player's message is rejected.
+type ErrorReply struct {
+	Type  string `json:"type"`
+	Error string `json:"error"`
+}
+
+// DefaultErrorReply encodes err as JSON, e.g. {"type":"error","error":"invalid move"}.
+func DefaultErrorReply(err error) []byte {
+	data, _ := json.Marshal(ErrorReply{
+		Type:  "error",
+		Error: err.Error(),
+	})
+	return data
+}
@@ -0,0 +1,262 @@
+package goroom
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type EventType int
+
+const (
+	EventPlayerConnected EventType = iota
+	EventPlayerDisconnected
+	EventPlayerRemoved
+	EventStatusChanged
+	EventRoomStopped
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventPlayerConnected:
+		return "PlayerConnected"
+	case EventPlayerDisconnected:
+		return "PlayerDisconnected"
+	case EventPlayerRemoved:
+		return "PlayerRemoved"
+	case EventStatusChanged:
+		return "StatusChanged"
+	case EventRoomStopped:
+		return "RoomStopped"
+	default:
+		return "Unknown"
+	}
+}
+
+// Event is something that happened in a room. Player is only set for player events and Status for
+// EventStatusChanged.
+type Event[RoomId comparable, PlayerID comparable] struct {
+	Type   EventType
+	Room   RoomId
+	Player PlayerID
+	Status RoomStatus
+	Time   time.Time
+}
+
+func (e Event[RoomId, PlayerID]) isPlayerEvent() bool {
+	return e.Type == EventPlayerConnected || e.Type == EventPlayerDisconnected || e.Type == EventPlayerRemoved
+}
+
+func (e Event[RoomId, PlayerID]) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Type   string    `json:"type"`
+		Room   RoomId    `json:"room"`
+		Player *PlayerID `json:"player,omitempty"`
+		Status string    `json:"status,omitempty"`
+		Time   time.Time `json:"time"`
+	}{
+		Type: e.Type.String(),
+		Room: e.Room,
+		Time: e.Time,
+	}
+	if e.isPlayerEvent() {
+		v.Player = &e.Player
+	}
+	if e.Type == EventStatusChanged {
+		v.Status = e.Status.String()
+	}
+	return json.Marshal(v)
+}
+
+const defaultEventBuffer = 64
+
+// eventBus fans room events out to subscribers. Publishing never blocks: a subscriber whose buffer is full misses
+// the event.
+type eventBus[RoomId comparable, PlayerID comparable] struct {
+	mu     sync.Mutex
+	subs   map[chan Event[RoomId, PlayerID]]struct{}
+	closed bool
+}
+
+func (b *eventBus[RoomId, PlayerID]) subscribe(buffer int) (<-chan Event[RoomId, PlayerID], func()) {
+	if buffer <= 0 {
+		buffer = defaultEventBuffer
+	}
+	ch := make(chan Event[RoomId, PlayerID], buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs == nil {
+		b.subs = make(map[chan Event[RoomId, PlayerID]]struct{})
+	}
+	b.subs[ch] = struct{}{}
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *eventBus[RoomId, PlayerID]) publish(event Event[RoomId, PlayerID]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// close ends every subscription; later subscriptions receive a closed channel.
+func (b *eventBus[RoomId, PlayerID]) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+	b.closed = true
+}
+
+// Subscribe returns a channel of the room's events and a function to unsubscribe. Events are dropped for a
+// subscriber whose buffer is full rather than blocking the room. The channel is closed when the room stops.
+// A buffer of zero or less uses a default.
+func (room *Room[RoomId, PlayerID]) Subscribe(buffer int) (<-chan Event[RoomId, PlayerID], func()) {
+	return room.events.subscribe(buffer)
+}
+
+// eventRecords maps the events that are also written to a room's recording.
+var eventRecords = map[EventType]RecordKind{
+	EventPlayerConnected:    RecordConnect,
+	EventPlayerDisconnected: RecordDisconnect,
+	EventPlayerRemoved:      RecordRemove,
+	EventRoomStopped:        RecordStopped,
+}
+
+// publish queues a player event, or EventRoomStopped, for delivery. It is called with room.mu held by the change
+// the event reports, so events queue in the order of the changes; deliverEvents must be called after unlocking.
+func (room *Room[RoomId, PlayerID]) publish(eventType EventType, player PlayerID) {
+	room.queueEvent(Event[RoomId, PlayerID]{
+		Type:   eventType,
+		Room:   room.ID,
+		Player: player,
+		Time:   room.clock.Now(),
+	})
+}
+
+// publishStatus queues EventStatusChanged, as publish does.
+func (room *Room[RoomId, PlayerID]) publishStatus(status RoomStatus) {
+	room.queueEvent(Event[RoomId, PlayerID]{
+		Type:   EventStatusChanged,
+		Room:   room.ID,
+		Status: status,
+		Time:   room.clock.Now(),
+	})
+}
+
+func (room *Room[RoomId, PlayerID]) queueEvent(event Event[RoomId, PlayerID]) {
+	room.pendingMu.Lock()
+	defer room.pendingMu.Unlock()
+	room.pending = append(room.pending, event)
+}
+
+// deliverEvents records, shares and publishes the queued events in order. Only one goroutine delivers at a time, so
+// an event queued before the call has been delivered when it returns. The caller must not hold room.mu.
+func (room *Room[RoomId, PlayerID]) deliverEvents() {
+	room.deliverMu.Lock()
+	defer room.deliverMu.Unlock()
+	for {
+		room.pendingMu.Lock()
+		events := room.pending
+		room.pending = nil
+		room.pendingMu.Unlock()
+		if len(events) == 0 {
+			return
+		}
+		for _, event := range events {
+			room.deliverEvent(event)
+		}
+	}
+}
+
+func (room *Room[RoomId, PlayerID]) deliverEvent(event Event[RoomId, PlayerID]) {
+	switch event.Type {
+	case EventStatusChanged:
+		room.record(RecordStatus, event.Player, event.Status, nil)
+	default:
+		if kind, ok := eventRecords[event.Type]; ok {
+			room.record(kind, event.Player, 0, nil)
+		}
+		if room.cluster != nil && event.Type != EventRoomStopped {
+			room.cluster.sendPresence(event.Type, event.Player)
+		}
+	}
+	room.events.publish(event)
+}
+
+// unlock releases room.mu and delivers the events published while it was held.
+func (room *Room[RoomId, PlayerID]) unlock() {
+	room.mu.Unlock()
+	room.deliverEvents()
+}
+
+const eventStreamKeepAlive = 30 * time.Second
+
+// HandleEvents streams the room's events to an observer as server-sent events, with the event type as the SSE
+// event name and the JSON encoded Event as its data. The stream ends when the request is cancelled or the room
+// stops.
+func (room *Room[RoomId, PlayerID]) HandleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		events, unsubscribe := room.Subscribe(0)
+		defer unsubscribe()
+
+		sl := room.Slogger.With("func", "room.HandleEvents")
+		sl.Debug("observer subscribed")
+		defer sl.Debug("observer unsubscribed")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := room.clock.NewTicker(eventStreamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C():
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					sl.Error("marshal event", "err", err)
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
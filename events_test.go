@@ -0,0 +1,150 @@
+package goroom
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	httptest2 "github.com/getlantern/httptest"
+)
+
+func nextEvent(t *testing.T, events <-chan Event[string, string]) Event[string, string] {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return Event[string, string]{}
+}
+
+func TestRoom_Subscribe(t *testing.T) {
+	t.Run("should publish the player lifecycle and status changes", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-events", Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		events, unsubscribe := room.Subscribe(0)
+		defer unsubscribe()
+
+		room.HandleSocketWithPlayer("player1", func(w http.ResponseWriter, r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})(httptest2.NewRecorder(nil), newUpgradeRequest(t, context.Background()))
+
+		// The recorder closes the connection straight away, so the player disconnects.
+		for _, want := range []EventType{EventPlayerConnected, EventPlayerDisconnected} {
+			if event := nextEvent(t, events); event.Type != want || event.Player != "player1" || event.Room != "test-room-events" {
+				t.Errorf("expected %v for player1, got %+v", want, event)
+			}
+		}
+
+		room.SetStatus(Locked)
+		if event := nextEvent(t, events); event.Type != EventStatusChanged || event.Status != Locked {
+			t.Errorf("expected status change to Locked, got %+v", event)
+		}
+		if event := nextEvent(t, events); event.Type != EventPlayerRemoved || event.Player != "player1" {
+			t.Errorf("expected player1 to be removed, got %+v", event)
+		}
+
+		room.Stop()
+		if event := nextEvent(t, events); event.Type != EventRoomStopped {
+			t.Errorf("expected room stopped, got %+v", event)
+		}
+		if _, ok := <-events; ok {
+			t.Error("expected the channel to be closed when the room stops")
+		}
+		if _, ok := <-func() <-chan Event[string, string] { ch, _ := room.Subscribe(0); return ch }(); ok {
+			t.Error("expected subscriptions to a stopped room to be closed")
+		}
+	})
+	t.Run("should drop events for a full subscriber rather than block", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-events", Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		events, unsubscribe := room.Subscribe(1)
+		room.SetStatus(Locked)
+		room.SetStatus(Open)
+		if event := nextEvent(t, events); event.Status != Locked {
+			t.Errorf("expected the first event to be kept, got %+v", event)
+		}
+		unsubscribe()
+		unsubscribe()
+		if _, ok := <-events; ok {
+			t.Error("expected the channel to be closed on unsubscribe")
+		}
+	})
+}
+
+func TestEvent_MarshalJSON(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		event Event[string, int]
+		want  string
+	}{
+		{
+			event: Event[string, int]{Type: EventPlayerConnected, Room: "r", Player: 0, Time: at},
+			want:  `{"type":"PlayerConnected","room":"r","player":0,"time":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			event: Event[string, int]{Type: EventStatusChanged, Room: "r", Status: Locked, Time: at},
+			want:  `{"type":"StatusChanged","room":"r","status":"Locked","time":"2024-01-02T03:04:05Z"}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.event)
+		if err != nil {
+			t.Fatalf("Marshal returned error: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, got)
+		}
+	}
+}
+
+func TestRoom_HandleEvents(t *testing.T) {
+	room, err := NewRoom[string, string](context.Background(), "test-room-sse", Options[string]{})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	srv := httptest.NewServer(room.HandleEvents())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected an event stream, got %s", ct)
+	}
+
+	room.SetStatus(Locked)
+	room.Stop()
+
+	body := bufio.NewScanner(resp.Body)
+	var lines []string
+	for body.Scan() {
+		lines = append(lines, body.Text())
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"event: StatusChanged\ndata: {\"type\":\"StatusChanged\",\"room\":\"test-room-sse\",\"status\":\"Locked\"",
+		"event: RoomStopped\ndata: {\"type\":\"RoomStopped\"",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected stream to contain %q, got %s", want, got)
+		}
+	}
+}
@@ -20,21 +20,25 @@ type ChatRoom struct {
 	Slogger *slog.Logger
 }
 
-func NewChatRoom(ctx context.Context) *ChatRoom {
+func NewChatRoom(ctx context.Context) (*ChatRoom, error) {
 	cr := &ChatRoom{
 		users:   NewUserStore(),
 		msgs:    make([]ChatMessage, 0),
 		Slogger: slog.Default(),
 	}
 
-	cr.Room = goroom.NewRoom[string, UserId](ctx, "room", goroom.Options[UserId]{
+	room, err := goroom.NewRoom[string, UserId](ctx, "room", goroom.Options[UserId]{
 		OnConnect:    cr.OnConnect,
 		OnDisconnect: cr.OnDisconnect,
 		OnMessage:    cr.OnMessage,
 		Slogger:      cr.Slogger,
 	})
+	if err != nil {
+		return nil, err
+	}
+	cr.Room = room
 
-	return cr
+	return cr, nil
 }
 
 func (cr *ChatRoom) Start() {
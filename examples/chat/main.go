@@ -24,7 +24,10 @@ func main() {
 
 	slog.SetDefault(slogger)
 
-	cr := chat.NewChatRoom(context.Background())
+	cr, err := chat.NewChatRoom(context.Background())
+	if err != nil {
+		panic(err)
+	}
 	cr.Start()
 
 	s := http.Server{
@@ -30,6 +30,7 @@ func RandStringLength(n int) string {
 
 var playerStore sync.Map
 var lobbyStore sync.Map
+var roomManager = goroom.NewRoomManager[RoomIdentifier, PlayerIdentifier]()
 
 type PlayerIdentifier = int8
 type RoomIdentifier = string
@@ -118,7 +119,7 @@ func (l *Lobby) changeRoomStatus(newStatus string) {
 	l.Room.SendMessageToAllPlayers(data)
 }
 
-func NewLobby(parentCtx context.Context, owner Player) *Lobby {
+func NewLobby(parentCtx context.Context, owner Player) (*Lobby, error) {
 
 	roomId := RandStringLength(6)
 	lobby := &Lobby{
@@ -126,17 +127,21 @@ func NewLobby(parentCtx context.Context, owner Player) *Lobby {
 		allocatedPlayers: []PlayerIdentifier{owner.ID},
 	}
 
-	lobby.Room = goroom.NewRoom[RoomIdentifier, PlayerIdentifier](parentCtx, roomId, goroom.Options[PlayerIdentifier]{
+	room, err := goroom.NewRoom[RoomIdentifier, PlayerIdentifier](parentCtx, roomId, goroom.Options[PlayerIdentifier]{
 		OnConnect:     lobby.OnConnect,
 		OnDisconnect:  lobby.OnDisconnect,
 		OnMessage:     lobby.ProcessMessage,
 		OnRemove:      lobby.OnDisconnect,
 		CleanupPeriod: time.Second * 10,
 	})
+	if err != nil {
+		return nil, err
+	}
+	lobby.Room = room
 
 	go lobby.Room.Start()
 
-	return lobby
+	return lobby, nil
 }
 
 func main() {
@@ -154,6 +159,8 @@ func main() {
 	}
 
 	r.Mount("/debug", middleware.Profiler())
+	// No authorization as this is only an example
+	r.Mount("/admin", http.StripPrefix("/admin", goroom.NewAdminHandler(roomManager, goroom.AdminOptions{})))
 
 	r.HandleFunc("/api/players", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -256,10 +263,17 @@ func main() {
 		if player.ID == 0 {
 		}
 
-		lobby := NewLobby(mainCtx, *player)
+		lobby, err := NewLobby(mainCtx, *player)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		slog.Info("new lobby created", "lobbyId", lobby.ID)
 		lobbyStore.Store(lobby.ID, lobby)
+		if err := roomManager.Add(lobby.Room); err != nil {
+			slog.Error("registering lobby", "err", err)
+		}
 		jsonResponse(w, lobby.toResponse())
 	})
 
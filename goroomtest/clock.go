@@ -0,0 +1,133 @@
+package goroomtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chilledoj/goroom"
+)
+
+// FakeClock is a goroom.Clock that only moves when advanced, so cleanup, pings and timeouts can be tested without
+// sleeping. Pass it as goroom.Options.Clock.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+	changed chan struct{}
+}
+
+var _ goroom.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a clock stopped at start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start, changed: make(chan struct{})}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) goroom.Ticker {
+	if d <= 0 {
+		panic("goroomtest: non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) goroom.Timer {
+	return c.add(d, 0)
+}
+
+func (c *FakeClock) add(d, period time.Duration) *fakeWaiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &fakeWaiter{clock: c, at: c.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	c.notify()
+	return w
+}
+
+// notify wakes BlockUntil. The caller must hold c.mu.
+func (c *FakeClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// Advance moves the clock forward, firing every timer and ticker that falls due in order. As with time.Ticker,
+// a ticker that is not being read drops ticks.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		var next *fakeWaiter
+		for _, w := range c.waiters {
+			if !w.at.After(end) && (next == nil || w.at.Before(next.at)) {
+				next = w
+			}
+		}
+		if next == nil {
+			break
+		}
+		c.now = next.at
+		select {
+		case next.ch <- c.now:
+		default:
+		}
+		if next.period > 0 {
+			next.at = next.at.Add(next.period)
+		} else {
+			c.remove(next)
+		}
+	}
+	c.now = end
+}
+
+// BlockUntil waits until at least n timers and tickers are active. Use it before Advance to make sure the code
+// under test has started waiting, for example for the room's cleanup ticker after Start.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		active, changed := len(c.waiters), c.changed
+		c.mu.Unlock()
+		if active >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// remove stops the waiter, reporting whether it was active. The caller must hold c.mu.
+func (c *FakeClock) remove(w *fakeWaiter) bool {
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			c.notify()
+			return true
+		}
+	}
+	return false
+}
+
+type fakeWaiter struct {
+	clock  *FakeClock
+	at     time.Time
+	period time.Duration
+	ch     chan time.Time
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.ch
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.remove(w)
+}
+
+type fakeTicker struct{ *fakeWaiter }
+
+func (t fakeTicker) Stop() { t.fakeWaiter.Stop() }
@@ -0,0 +1,150 @@
+package goroomtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+	"github.com/chilledoj/goroom/goroomtest"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t.Run("should fire timers and tickers as it advances", func(t *testing.T) {
+		clock := goroomtest.NewFakeClock(start)
+		timer := clock.NewTimer(5 * time.Second)
+		ticker := clock.NewTicker(2 * time.Second)
+
+		clock.Advance(time.Second)
+		select {
+		case <-timer.C():
+			t.Fatal("timer fired early")
+		case <-ticker.C():
+			t.Fatal("ticker fired early")
+		default:
+		}
+
+		clock.Advance(time.Second)
+		if got := <-ticker.C(); !got.Equal(start.Add(2 * time.Second)) {
+			t.Errorf("expected tick at 2s, got %v", got)
+		}
+		clock.Advance(3 * time.Second)
+		if got := <-timer.C(); !got.Equal(start.Add(5 * time.Second)) {
+			t.Errorf("expected timer at 5s, got %v", got)
+		}
+		if got := <-ticker.C(); !got.Equal(start.Add(4 * time.Second)) {
+			t.Errorf("expected tick at 4s, got %v", got)
+		}
+		if !clock.Now().Equal(start.Add(5 * time.Second)) {
+			t.Errorf("expected now to be 5s, got %v", clock.Now())
+		}
+		if timer.Stop() {
+			t.Error("expected a fired timer to be inactive")
+		}
+	})
+	t.Run("should not fire stopped timers", func(t *testing.T) {
+		clock := goroomtest.NewFakeClock(start)
+		timer := clock.NewTimer(time.Second)
+		if !timer.Stop() {
+			t.Error("expected the timer to be active")
+		}
+		clock.Advance(time.Minute)
+		select {
+		case <-timer.C():
+			t.Error("stopped timer fired")
+		default:
+		}
+	})
+}
+
+func TestRoom_CleanupWithFakeClock(t *testing.T) {
+	clock := goroomtest.NewFakeClock(time.Now())
+	removed := make(chan string, 1)
+	room, err := goroom.NewRoom[string, string](context.Background(), "clock", goroom.Options[string]{
+		CleanupPeriod: 30 * time.Second,
+		Clock:         clock,
+		OnRemove:      func(player string) { removed <- player },
+	})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	goroomtest.StartRoom(t, room)
+	// Wait for the room's cleanup ticker.
+	clock.BlockUntil(1)
+
+	goroomtest.Connect(t, room, "alice").Disconnect()
+	lastSeen := room.GetPlayerPresence("alice").LastSeen
+	if !lastSeen.Equal(clock.Now()) {
+		t.Errorf("expected lastSeen to come from the clock, got %v", lastSeen)
+	}
+
+	// The first tick is exactly one period after the disconnect, which is not yet long enough.
+	clock.Advance(30 * time.Second)
+	select {
+	case <-removed:
+		t.Fatal("alice removed too early")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case player := <-removed:
+		if player != "alice" {
+			t.Errorf("expected alice to be removed, got %s", player)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for alice to be removed")
+	}
+}
+
+func TestRoom_SnapshotWithFakeClock(t *testing.T) {
+	clock := goroomtest.NewFakeClock(time.Now())
+	store, err := goroom.NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSnapshotStore returned error: %v", err)
+	}
+	snapshotting := make(chan struct{}, 1)
+	release := make(chan struct{})
+	var room *goroom.Room[string, string]
+	room, err = goroom.NewRoom[string, string](context.Background(), "clock", goroom.Options[string]{
+		SnapshotStore:    store,
+		SnapshotInterval: time.Minute,
+		SnapshotState: func() ([]byte, error) {
+			select {
+			case snapshotting <- struct{}{}:
+			default:
+			}
+			<-release
+			return []byte("state"), nil
+		},
+		OnMessage: func(player string, message []byte) {
+			room.SendMessageToPlayer(player, message)
+		},
+		Clock: clock,
+	})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	goroomtest.StartRoom(t, room)
+	// Wait for the room's cleanup and snapshot tickers.
+	clock.BlockUntil(2)
+	alice := goroomtest.Connect(t, room, "alice")
+
+	clock.Advance(time.Minute)
+	select {
+	case <-snapshotting:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the periodic snapshot")
+	}
+	// The snapshot is still being taken, but messages are handled regardless.
+	alice.Send([]byte("move"))
+	alice.ExpectMessage([]byte("move"))
+
+	close(release)
+	room.Stop()
+	room.Wait()
+	if _, err := goroom.RestoreRoom[string, string](context.Background(), "clock", goroom.Options[string]{SnapshotStore: store}); err != nil {
+		t.Errorf("expected a saved snapshot, got %v", err)
+	}
+}
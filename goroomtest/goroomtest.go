@@ -0,0 +1,146 @@
+// Package goroomtest provides helpers for testing room logic without websockets. Players are connected to a
+// goroom.Room over an in-process transport, so CanJoin and the room's callbacks run as they would in production.
+package goroomtest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+)
+
+// Timeout is how long the Expect helpers wait before failing the test.
+var Timeout = time.Second
+
+// Player is a fake player connected to a room.
+type Player[RoomId comparable, PlayerID comparable] struct {
+	t      testing.TB
+	room   *goroom.Room[RoomId, PlayerID]
+	ID     PlayerID
+	client *goroom.MemoryClient[PlayerID]
+}
+
+// Connect joins the player to the room, failing the test if the room refuses them. The player is disconnected when
+// the test finishes.
+func Connect[RoomId comparable, PlayerID comparable](t testing.TB, room *goroom.Room[RoomId, PlayerID], player PlayerID) *Player[RoomId, PlayerID] {
+	t.Helper()
+	p, err := TryConnect(t, room, player)
+	if err != nil {
+		t.Fatalf("goroomtest: connect %v: %v", player, err)
+	}
+	return p
+}
+
+// TryConnect is Connect for tests that expect the room to refuse the player, such as goroom.ErrCannotJoin.
+func TryConnect[RoomId comparable, PlayerID comparable](t testing.TB, room *goroom.Room[RoomId, PlayerID], player PlayerID) (*Player[RoomId, PlayerID], error) {
+	t.Helper()
+	client, err := room.ConnectMemory(context.Background(), player)
+	if err != nil {
+		return nil, err
+	}
+	t.Cleanup(client.Close)
+	return &Player[RoomId, PlayerID]{t: t, room: room, ID: player, client: client}, nil
+}
+
+// Send sends a message to the room as the player, failing the test if it is rejected.
+func (p *Player[RoomId, PlayerID]) Send(message []byte) {
+	p.t.Helper()
+	if err := p.client.Send(message); err != nil {
+		p.t.Fatalf("goroomtest: send from %v: %v", p.ID, err)
+	}
+}
+
+// TrySend is Send for tests that expect the message to be rejected.
+func (p *Player[RoomId, PlayerID]) TrySend(message []byte) error {
+	return p.client.Send(message)
+}
+
+// Expect returns the next message sent to the player, failing the test if none arrives within Timeout.
+func (p *Player[RoomId, PlayerID]) Expect() []byte {
+	p.t.Helper()
+	select {
+	case msg, ok := <-p.client.Receive():
+		if !ok {
+			p.t.Fatalf("goroomtest: %v was disconnected while waiting for a message", p.ID)
+		}
+		return msg
+	case <-time.After(Timeout):
+		p.t.Fatalf("goroomtest: timed out waiting for a message to %v", p.ID)
+	}
+	return nil
+}
+
+// ExpectMessage fails the test unless the next message sent to the player is want.
+func (p *Player[RoomId, PlayerID]) ExpectMessage(want []byte) {
+	p.t.Helper()
+	if got := p.Expect(); !bytes.Equal(got, want) {
+		p.t.Errorf("goroomtest: expected %v to receive %q, got %q", p.ID, want, got)
+	}
+}
+
+// ExpectNoMessage fails the test if a message is sent to the player within d.
+func (p *Player[RoomId, PlayerID]) ExpectNoMessage(d time.Duration) {
+	p.t.Helper()
+	select {
+	case msg, ok := <-p.client.Receive():
+		if ok {
+			p.t.Errorf("goroomtest: expected no message to %v, got %q", p.ID, msg)
+		}
+	case <-time.After(d):
+	}
+}
+
+// ExpectDisconnected fails the test unless the room closes the player's connection within Timeout, for example
+// when kicked.
+func (p *Player[RoomId, PlayerID]) ExpectDisconnected() {
+	p.t.Helper()
+	select {
+	case <-p.client.Done():
+	case <-time.After(Timeout):
+		p.t.Fatalf("goroomtest: timed out waiting for %v to be disconnected", p.ID)
+	}
+}
+
+// Disconnect drops the player's connection and waits for the room to process it, so the player is known but not
+// connected when it returns.
+func (p *Player[RoomId, PlayerID]) Disconnect() {
+	p.t.Helper()
+	p.client.Close()
+	WaitForPresence(p.t, p.room, p.ID, false)
+}
+
+// Reconnect disconnects the player if necessary and connects them again.
+func (p *Player[RoomId, PlayerID]) Reconnect() *Player[RoomId, PlayerID] {
+	p.t.Helper()
+	if p.room.GetPlayerPresence(p.ID).IsConnected {
+		p.Disconnect()
+	}
+	return Connect(p.t, p.room, p.ID)
+}
+
+// WaitForPresence waits up to Timeout for the room to report the player as connected or disconnected. A player
+// that has been removed from the room is not connected.
+func WaitForPresence[RoomId comparable, PlayerID comparable](t testing.TB, room *goroom.Room[RoomId, PlayerID], player PlayerID, connected bool) {
+	t.Helper()
+	deadline := time.Now().Add(Timeout)
+	for room.GetPlayerPresence(player).IsConnected != connected {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroomtest: timed out waiting for %v to have IsConnected=%v", player, connected)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// ExpireDisconnected removes every disconnected player from the room straight away, as the periodic cleanup would
+// once their CleanupPeriod has passed.
+func ExpireDisconnected[RoomId comparable, PlayerID comparable](room *goroom.Room[RoomId, PlayerID]) {
+	room.CleanUpPlayersOlderThan(0)
+}
+
+// StartRoom starts the room and stops it when the test finishes.
+func StartRoom[RoomId comparable, PlayerID comparable](t testing.TB, room *goroom.Room[RoomId, PlayerID]) {
+	go room.Start()
+	t.Cleanup(room.Stop)
+}
@@ -0,0 +1,86 @@
+package goroomtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+	"github.com/chilledoj/goroom/goroomtest"
+)
+
+// newEchoRoom returns a room that broadcasts each message it receives.
+func newEchoRoom(t *testing.T, opts goroom.Options[string]) *goroom.Room[string, string] {
+	t.Helper()
+	var room *goroom.Room[string, string]
+	opts.OnMessage = func(player string, message []byte) {
+		room.SendMessageToAllPlayers(append([]byte(player+": "), message...))
+	}
+	room, err := goroom.NewRoom[string, string](context.Background(), "echo", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	goroomtest.StartRoom(t, room)
+	return room
+}
+
+func TestPlayer_Messages(t *testing.T) {
+	room := newEchoRoom(t, goroom.Options[string]{})
+	alice := goroomtest.Connect(t, room, "alice")
+	bob := goroomtest.Connect(t, room, "bob")
+
+	alice.Send([]byte("hello"))
+	alice.ExpectMessage([]byte("alice: hello"))
+	bob.ExpectMessage([]byte("alice: hello"))
+	bob.ExpectNoMessage(10 * time.Millisecond)
+}
+
+func TestPlayer_Callbacks(t *testing.T) {
+	connected := make(chan string, 2)
+	removed := make(chan string, 1)
+	room := newEchoRoom(t, goroom.Options[string]{
+		OnConnect: func(player string) { connected <- player },
+		OnRemove:  func(player string) { removed <- player },
+	})
+	wait := func(ch chan string, want string) {
+		t.Helper()
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Errorf("expected %s, got %s", want, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+
+	alice := goroomtest.Connect(t, room, "alice")
+	wait(connected, "alice")
+	if _, err := goroomtest.TryConnect(t, room, "alice"); !errors.Is(err, goroom.ErrCannotJoin) {
+		t.Errorf("expected a second connection to be refused, got %v", err)
+	}
+
+	alice = alice.Reconnect()
+	wait(connected, "alice")
+	alice.Disconnect()
+	if presence := room.GetPlayerPresence("alice"); presence.IsConnected || presence.LastSeen.IsZero() {
+		t.Errorf("expected alice to be known but disconnected, got %+v", presence)
+	}
+
+	goroomtest.ExpireDisconnected(room)
+	wait(removed, "alice")
+}
+
+func TestPlayer_Kicked(t *testing.T) {
+	room := newEchoRoom(t, goroom.Options[string]{
+		RateLimit: goroom.RateLimit{MessagesPerSecond: 1, MessageBurst: 1, Action: goroom.RateLimitKick},
+	})
+	alice := goroomtest.Connect(t, room, "alice")
+	alice.Send([]byte("one"))
+	if err := alice.TrySend([]byte("two")); !errors.Is(err, goroom.ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+	alice.ExpectDisconnected()
+	goroomtest.WaitForPresence(t, room, "alice", false)
+}
@@ -1,6 +1,7 @@
 package goroom
 
 import (
+	"context"
 	"errors"
 	"github.com/gobwas/ws"
 	"net/http"
@@ -12,36 +13,81 @@ type GetPlayerIDFromRequester[PlayerId comparable] interface {
 }
 type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
 
+var (
+	ErrNilPlayerID = errors.New("playerID is nil")
+	ErrCannotJoin  = errors.New("player cannot join room")
+)
+
 func (room *Room[RoomId, PlayerId]) HandleSocketWithPlayer(playerID PlayerId, onError ErrorHandler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := room.tracer.Start(r.Context(), "goroom.connect",
+			Attr("goroom.room", room.metricsLabel),
+			Attr("goroom.player", playerID),
+		)
+		defer span.End()
+
 		var zero PlayerId
 		if playerID == zero {
-			onError(w, r, errors.New("playerID is nil"))
+			err := ErrNilPlayerID
+			span.RecordError(err)
+			onError(w, r, err)
 			return
 		}
 		if !room.CanJoin(playerID) {
-			onError(w, r, errors.New("player cannot join room"))
+			err := ErrCannotJoin
+			span.RecordError(err)
+			onError(w, r, err)
 			return
 		}
 
 		conn, _, _, err := ws.UpgradeHTTP(r, w)
 		if err != nil {
+			span.RecordError(err)
 			onError(w, r, err)
 			return
 		}
 		room.Slogger.Info("new socket connection", "player", playerID)
 
-		ss := NewSocketSession[PlayerId](conn, playerID, room.messages)
+		_, err = room.admit(ctx, playerID, func(cfg sessionConfig) SocketSessioner[PlayerId] {
+			return newSocketSession[PlayerId](conn, playerID, room.messages, cfg)
+		})
+		if err != nil {
+			// The connection has been upgraded, so there is no response to report the error with.
+			span.RecordError(err)
+			room.Slogger.Info("socket connection refused", "player", playerID, "err", err)
+			conn.Close()
+		}
+	}
+}
 
-		room.mu.Lock()
-		room.players[playerID] = ss
+// admit registers a newly connected session for the player and calls OnConnect. It checks the CanJoin rules again
+// with the room locked, as the player may have joined elsewhere or the room may have stopped since the caller
+// checked, and returns ErrCannotJoin without calling newSession if they fail; the caller must then close the
+// connection. newSession is called with the room locked, so it must not block.
+func (room *Room[RoomId, PlayerId]) admit(ctx context.Context, playerID PlayerId, newSession func(cfg sessionConfig) SocketSessioner[PlayerId]) (SocketSessioner[PlayerId], error) {
+	room.mu.Lock()
+	if !room.canJoin(playerID) {
 		room.mu.Unlock()
+		return nil, ErrCannotJoin
+	}
+	pctx := room.newPlayerContext(ctx, playerID)
+	cfg := room.sessionConfig()
+	cfg.parent = pctx
+	ss := newSession(cfg)
+	room.players[playerID] = ss
+	room.publish(EventPlayerConnected, playerID)
+	room.unlock()
+	room.metrics.PlayerConnected(room.metricsLabel)
 
+	if room.onConnect != nil {
+		room.callbacks.add()
 		go func() {
+			defer room.callbacks.done()
 			<-time.After(time.Millisecond * 1)
-			room.opts.OnConnect(playerID)
+			room.runCallback(pctx, "OnConnect", playerID, func(ctx context.Context) { room.onConnect(ctx, playerID) })
 		}()
 	}
+	return ss, nil
 }
 
 func (room *Room[RoomId, PlayerId]) HandleSocket(playerStore GetPlayerIDFromRequester[PlayerId], onError ErrorHandler) http.HandlerFunc {
@@ -52,19 +98,35 @@ func (room *Room[RoomId, PlayerId]) HandleSocket(playerStore GetPlayerIDFromRequ
 }
 
 func (room *Room[RoomId, PlayerId]) CanJoin(playerID PlayerId) bool {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	return room.canJoin(playerID)
+}
+
+// canJoin is CanJoin for a caller holding room.mu.
+func (room *Room[RoomId, PlayerId]) canJoin(playerID PlayerId) bool {
 	if room.Status == Inactive {
 		return false
 	}
 
 	// Open OR Locked
-	room.mu.RLock()
-	defer room.mu.RUnlock()
+	if room.draining || room.stopped {
+		return false
+	}
 
 	p, ok := room.players[playerID]
 	if ok && p != nil {
 		// Player is already connected. Only allow one connection.
 		return false
 	}
+	if room.cluster != nil {
+		remote, known := room.cluster.presence(playerID)
+		if known && remote.IsConnected {
+			// Player is connected to another node.
+			return false
+		}
+		ok = ok || known
+	}
 	if !ok && room.Status == Locked {
 		// Locked Room and player was not previously connected when locked
 		return false
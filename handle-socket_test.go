@@ -4,10 +4,13 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	httptest2 "github.com/getlantern/httptest"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -20,13 +23,13 @@ func TestRoom_HandleSocketWithPlayer(t *testing.T) {
 		var player string
 		handler := newMockHandler[string]()
 
-		room := NewRoom[string, string](context.Background(), "test-socket-error", Options[string]{
+		room, err := NewRoom[string, string](context.Background(), "test-socket-error", Options[string]{
 			OnConnect:    handler.OnConnect,
 			OnDisconnect: handler.OnDisconnect,
 			OnMessage:    handler.OnMessage,
 		})
-		if room == nil {
-			t.Fatal("NewRoom returned nil")
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
 		}
 
 		var httpErr error
@@ -68,13 +71,13 @@ func TestRoom_HandleSocketWithPlayer(t *testing.T) {
 		player := "player-1"
 		handler := newMockHandler[string]()
 
-		room := NewRoom[string, string](context.Background(), "test-socket-connect", Options[string]{
+		room, err := NewRoom[string, string](context.Background(), "test-socket-connect", Options[string]{
 			OnConnect:    handler.OnConnect,
 			OnDisconnect: handler.OnDisconnect,
 			OnMessage:    handler.OnMessage,
 		})
-		if room == nil {
-			t.Fatal("NewRoom returned nil")
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
 		}
 		defer room.Stop()
 
@@ -192,3 +195,61 @@ func TestRoom_CanJoin(t *testing.T) {
 		}
 	})
 }
+
+func TestRoom_admit(t *testing.T) {
+	t.Run("should admit only one of concurrent connections for a player", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-admit")
+		defer cleanup()
+
+		const attempts = 10
+		admitted := make(chan SocketSessioner[string], attempts)
+		var refused atomic.Int32
+		var wg sync.WaitGroup
+		for range attempts {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ss, err := room.admit(context.Background(), "player-1", func(sessionConfig) SocketSessioner[string] {
+					return newMockSocketSession[string]("player-1")
+				})
+				if errors.Is(err, ErrCannotJoin) {
+					refused.Add(1)
+					return
+				}
+				if err != nil {
+					t.Errorf("admit returned error: %v", err)
+					return
+				}
+				admitted <- ss
+			}()
+		}
+		wg.Wait()
+		close(admitted)
+
+		if got := refused.Load(); got != attempts-1 {
+			t.Errorf("expected %d connections to be refused, got %d", attempts-1, got)
+		}
+		ss := <-admitted
+		room.mu.RLock()
+		defer room.mu.RUnlock()
+		if room.players["player-1"] != ss {
+			t.Error("expected the admitted session to be the player's")
+		}
+	})
+	t.Run("should refuse a stopped room", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-admit-stopped")
+		cleanup()
+
+		created := false
+		_, err := room.admit(context.Background(), "player-1", func(sessionConfig) SocketSessioner[string] {
+			created = true
+			return newMockSocketSession[string]("player-1")
+		})
+		if !errors.Is(err, ErrCannotJoin) {
+			t.Errorf("expected ErrCannotJoin, got %v", err)
+		}
+		if created {
+			t.Error("expected no session to be created")
+		}
+	})
+}
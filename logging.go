@@ -0,0 +1,101 @@
+package goroom
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const defaultPayloadLogLimit = 256
+
+// PayloadLogging controls whether message payloads appear in debug logs. Payloads are omitted by default, as they
+// may contain player data; only their size is logged.
+type PayloadLogging struct {
+	Enabled bool
+	// Limit truncates logged payloads to this many bytes. Defaults to 256.
+	Limit int
+	// Redact, if set, is applied to each payload before it is truncated and logged.
+	Redact func(payload []byte) []byte
+}
+
+// attr returns the log attribute for a payload. The payload is only redacted and copied if the record is logged.
+func (pl PayloadLogging) attr(payload []byte) slog.Attr {
+	if !pl.Enabled {
+		return slog.Int("size", len(payload))
+	}
+	return slog.Any("payload", loggedPayload{logging: pl, payload: payload})
+}
+
+// loggedPayload is a payload as it appears in logs.
+type loggedPayload struct {
+	logging PayloadLogging
+	payload []byte
+}
+
+func (lp loggedPayload) LogValue() slog.Value {
+	payload := lp.payload
+	if lp.logging.Redact != nil {
+		payload = lp.logging.Redact(payload)
+	}
+	limit := lp.logging.Limit
+	if limit <= 0 {
+		limit = defaultPayloadLogLimit
+	}
+	if len(payload) > limit {
+		// Back off to the start of a rune so text is not cut mid-character. Binary payloads have no rune starts
+		// nearby, so they are cut at the limit.
+		cut := limit
+		for cut > limit-utf8.UTFMax && cut > 0 && !utf8.RuneStart(payload[cut]) {
+			cut--
+		}
+		if !utf8.RuneStart(payload[cut]) {
+			cut = limit
+		}
+		return slog.GroupValue(
+			slog.String("data", string(payload[:cut])),
+			slog.Int("truncated", len(payload)-cut),
+		)
+	}
+	return slog.StringValue(string(payload))
+}
+
+// levelHandler overrides the minimum level of the handler it wraps.
+type levelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+func (h levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
+
+var connectionIDs atomic.Uint64
+
+// nextConnectionID identifies a single connection in logs, distinguishing a player's reconnects.
+func nextConnectionID() uint64 {
+	return connectionIDs.Add(1)
+}
+
+func (room *Room[RoomId, PlayerID]) newLogger() *slog.Logger {
+	parent := room.opts.Slogger
+	if parent == nil {
+		parent = slog.Default()
+	}
+	if room.opts.LogLevel != nil {
+		parent = slog.New(levelHandler{level: room.opts.LogLevel, handler: parent.Handler()})
+	}
+	return parent.With("room", room.ID)
+}
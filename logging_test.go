@@ -0,0 +1,124 @@
+package goroom
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws/wsutil"
+)
+
+func TestPayloadLogging_Attr(t *testing.T) {
+	payload := []byte("secret move e2e4")
+	t.Run("should log only the size by default", func(t *testing.T) {
+		attr := PayloadLogging{}.attr(payload)
+		if attr.Key != "size" || attr.Value.Int64() != int64(len(payload)) {
+			t.Errorf("expected size attribute, got %v", attr)
+		}
+	})
+	t.Run("should truncate payloads over the limit", func(t *testing.T) {
+		attr := resolved(PayloadLogging{Enabled: true, Limit: 6}.attr(payload))
+		if got := attr.String(); !strings.Contains(got, "data=secret") || !strings.Contains(got, "truncated=10") {
+			t.Errorf("expected truncated payload, got %s", got)
+		}
+	})
+	t.Run("should truncate on a rune boundary", func(t *testing.T) {
+		attr := resolved(PayloadLogging{Enabled: true, Limit: 2}.attr([]byte("héé")))
+		if got := attr.String(); !strings.Contains(got, "data=h ") || !strings.Contains(got, "truncated=4") {
+			t.Errorf("expected the payload cut before the 'é', got %s", got)
+		}
+	})
+	t.Run("should redact before logging", func(t *testing.T) {
+		attr := resolved(PayloadLogging{Enabled: true, Redact: func(p []byte) []byte {
+			return bytes.ReplaceAll(p, []byte("secret"), []byte("***"))
+		}}.attr(payload))
+		if got := attr.Value.String(); got != "*** move e2e4" {
+			t.Errorf("expected redacted payload, got %q", got)
+		}
+	})
+	t.Run("should not redact payloads that are not logged", func(t *testing.T) {
+		redacted := 0
+		pl := PayloadLogging{Enabled: true, Redact: func(p []byte) []byte {
+			redacted++
+			return p
+		}}
+		var buf bytes.Buffer
+		sl := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		sl.Debug("message", pl.attr(payload))
+		if redacted != 0 {
+			t.Errorf("expected no redaction with debug logging off, got %d", redacted)
+		}
+		sl.Info("message", pl.attr(payload))
+		if redacted != 1 || !strings.Contains(buf.String(), "payload=\"secret move e2e4\"") {
+			t.Errorf("expected the payload to be redacted and logged once, got %d: %s", redacted, buf.String())
+		}
+	})
+}
+
+func resolved(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	return attr
+}
+
+func TestRoom_Logging(t *testing.T) {
+	t.Run("should give sessions the room's logger with player and connection IDs", func(t *testing.T) {
+		var buf syncBuffer
+		room, err := NewRoom[string, string](context.Background(), "test-room-logging", Options[string]{
+			Slogger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		serverConn, clientConn := net.Pipe()
+		session := newSocketSession(serverConn, "player1", make(chan SocketMessage[string], 10), room.sessionConfig())
+		t.Cleanup(func() {
+			session.Close()
+			_ = clientConn.Close()
+		})
+
+		if err := wsutil.WriteClientBinary(clientConn, []byte("hidden payload")); err != nil {
+			t.Fatalf("Failed to write client message: %v", err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(buf.String(), "ReadLoop message") {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for session log, got %s", buf.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		logs := buf.String()
+		for _, want := range []string{"room=test-room-logging", "player=player1", "connection=", "size=14"} {
+			if !strings.Contains(logs, want) {
+				t.Errorf("expected session logs to contain %q, got %s", want, logs)
+			}
+		}
+		if strings.Contains(logs, "hidden payload") {
+			t.Errorf("expected payload to be omitted from logs, got %s", logs)
+		}
+	})
+	t.Run("should apply the room's log level override", func(t *testing.T) {
+		var buf syncBuffer
+		room, err := NewRoom[string, string](context.Background(), "test-room-log-level", Options[string]{
+			Slogger:  slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+			LogLevel: slog.LevelWarn,
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		room.Slogger.Info("hidden")
+		room.Slogger.Warn("shown")
+		if logs := buf.String(); strings.Contains(logs, "hidden") || !strings.Contains(logs, "shown") {
+			t.Errorf("expected only warnings to be logged, got %s", logs)
+		}
+		room.SetRoomID("renamed")
+		room.Slogger.Info("hidden after rename")
+		if strings.Contains(buf.String(), "hidden after rename") {
+			t.Errorf("expected override to survive SetRoomID, got %s", buf.String())
+		}
+	})
+}
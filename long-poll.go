@@ -0,0 +1,402 @@
+package goroom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollTimeout     = 20 * time.Second
+	defaultPollIdleTimeout = 30 * time.Second
+	defaultPollBuffer      = 255
+)
+
+// LongPollOptions configures a room's long-polling transport. Every field is optional.
+type LongPollOptions struct {
+	// PollTimeout is how long a poll waits for messages before returning an empty response. Defaults to 20 seconds.
+	PollTimeout time.Duration
+	// IdleTimeout disconnects a player when no poll has been in progress for this long. It must be longer than
+	// PollTimeout. Defaults to 30 seconds.
+	IdleTimeout time.Duration
+	// Buffer is the number of unacknowledged messages kept for a player before further messages are dropped.
+	// Defaults to 255.
+	Buffer int
+}
+
+func (o *LongPollOptions) setDefaults() error {
+	if o.PollTimeout < 0 || o.IdleTimeout < 0 || o.Buffer < 0 {
+		return fmt.Errorf("%w: LongPollOptions values must not be negative", ErrInvalidOptions)
+	}
+	if o.PollTimeout == 0 {
+		o.PollTimeout = defaultPollTimeout
+	}
+	if o.IdleTimeout == 0 {
+		o.IdleTimeout = max(defaultPollIdleTimeout, o.PollTimeout+o.PollTimeout/2)
+	}
+	if o.Buffer == 0 {
+		o.Buffer = defaultPollBuffer
+	}
+	if o.IdleTimeout <= o.PollTimeout {
+		return fmt.Errorf("%w: IdleTimeout must be longer than PollTimeout", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// LongPoll is a fallback transport for clients behind proxies that buffer streaming responses. A player joins to
+// receive a session ID, then repeatedly polls for messages with a cursor acknowledging those already received, and
+// sends messages with separate requests. See HandleJoinWithPlayer, HandlePoll and HandleSend.
+type LongPoll[RoomId comparable, PlayerId comparable] struct {
+	room *Room[RoomId, PlayerId]
+	opts LongPollOptions
+
+	mu       sync.RWMutex
+	sessions map[string]*LongPollSession[PlayerId]
+}
+
+// NewLongPoll creates the room's long-polling transport. It returns an error wrapping ErrInvalidOptions if the
+// options are inconsistent.
+func (room *Room[RoomId, PlayerId]) NewLongPoll(opts LongPollOptions) (*LongPoll[RoomId, PlayerId], error) {
+	if err := opts.setDefaults(); err != nil {
+		return nil, err
+	}
+	return &LongPoll[RoomId, PlayerId]{
+		room:     room,
+		opts:     opts,
+		sessions: make(map[string]*LongPollSession[PlayerId]),
+	}, nil
+}
+
+// LongPollJoin is the response to a successful join.
+type LongPollJoin struct {
+	Session string `json:"session"`
+	Cursor  uint64 `json:"cursor"`
+}
+
+// LongPollResponse is the response to a poll. Messages are base64 encoded in JSON. Cursor is the value to send with
+// the next poll.
+type LongPollResponse struct {
+	Messages [][]byte `json:"messages"`
+	Cursor   uint64   `json:"cursor"`
+}
+
+// HandleJoinWithPlayer is the long-polling equivalent of HandleSocketWithPlayer. It admits the player and responds
+// with a LongPollJoin.
+func (lp *LongPoll[RoomId, PlayerId]) HandleJoinWithPlayer(playerID PlayerId, onError ErrorHandler) http.HandlerFunc {
+	room := lp.room
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := room.tracer.Start(r.Context(), "goroom.connect",
+			Attr("goroom.room", room.metricsLabel),
+			Attr("goroom.player", playerID),
+			Attr("goroom.transport", "long-poll"),
+		)
+		defer span.End()
+
+		var zero PlayerId
+		if playerID == zero {
+			err := ErrNilPlayerID
+			span.RecordError(err)
+			onError(w, r, err)
+			return
+		}
+		if !room.CanJoin(playerID) {
+			err := ErrCannotJoin
+			span.RecordError(err)
+			onError(w, r, err)
+			return
+		}
+		id, err := newSessionID()
+		if err != nil {
+			span.RecordError(err)
+			onError(w, r, err)
+			return
+		}
+		room.Slogger.Info("new long-poll connection", "player", playerID)
+
+		_, err = room.admit(ctx, playerID, func(cfg sessionConfig) SocketSessioner[PlayerId] {
+			ss := newLongPollSession(id, playerID, room.messages, cfg, lp.opts)
+			lp.mu.Lock()
+			lp.sessions[id] = ss
+			lp.mu.Unlock()
+			ss.onClose = func() {
+				lp.mu.Lock()
+				delete(lp.sessions, id)
+				lp.mu.Unlock()
+			}
+			go ss.watch()
+			return ss
+		})
+		if err != nil {
+			span.RecordError(err)
+			onError(w, r, err)
+			return
+		}
+		writeJSON(w, LongPollJoin{Session: id})
+	}
+}
+
+// HandlePoll waits for messages for the session in the "session" query parameter, first discarding those up to
+// the "cursor" query parameter, and responds with a LongPollResponse. Errors passed to onError include
+// ErrNotConnected.
+func (lp *LongPoll[RoomId, PlayerId]) HandlePoll(onError ErrorHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ss, ok := lp.session(r)
+		if !ok {
+			onError(w, r, ErrNotConnected)
+			return
+		}
+		var cursor uint64
+		if c := r.URL.Query().Get("cursor"); c != "" {
+			var err error
+			if cursor, err = strconv.ParseUint(c, 10, 64); err != nil {
+				onError(w, r, fmt.Errorf("invalid cursor: %w", err))
+				return
+			}
+		}
+		messages, cursor, err := ss.poll(r.Context(), cursor, lp.opts.PollTimeout)
+		if err != nil {
+			onError(w, r, err)
+			return
+		}
+		if messages == nil {
+			messages = [][]byte{}
+		}
+		writeJSON(w, LongPollResponse{Messages: messages, Cursor: cursor})
+	}
+}
+
+// HandleSend sends the request body to the room as a message from the session's player, responding with 202
+// Accepted. Errors passed to onError include ErrNotConnected, ErrRateLimited and ErrMessageTooLarge.
+func (lp *LongPoll[RoomId, PlayerId]) HandleSend(onError ErrorHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ss, ok := lp.session(r)
+		if !ok {
+			onError(w, r, ErrNotConnected)
+			return
+		}
+		var body io.Reader = r.Body
+		if ss.cfg.maxMessageSize > 0 {
+			body = http.MaxBytesReader(w, r.Body, ss.cfg.maxMessageSize)
+		}
+		msg, err := io.ReadAll(body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				err = ErrMessageTooLarge
+			}
+			onError(w, r, err)
+			return
+		}
+		if err := ss.receive(msg); err != nil {
+			onError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (lp *LongPoll[RoomId, PlayerId]) session(r *http.Request) (*LongPollSession[PlayerId], bool) {
+	lp.mu.RLock()
+	defer lp.mu.RUnlock()
+	ss, ok := lp.sessions[r.URL.Query().Get("session")]
+	return ss, ok
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type pendingMessage struct {
+	seq  uint64
+	data []byte
+}
+
+// LongPollSession is a player's connection over the long-polling transport.
+type LongPollSession[PlayerId comparable] struct {
+	id          string
+	referenceID PlayerId
+
+	// The message bit
+	Messages chan SocketMessage[PlayerId]
+	mu       sync.Mutex
+	pending  []pendingMessage
+	seq      uint64
+	notify   chan struct{}
+	polling  int
+	lastPoll time.Time
+
+	// The limits bit
+	cfg     sessionConfig
+	opts    LongPollOptions
+	limiter *rateLimiter
+
+	// The concurrency bit
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	onClose func()
+}
+
+func newLongPollSession[PlayerId comparable](id string, referenceID PlayerId, messages chan SocketMessage[PlayerId], cfg sessionConfig, opts LongPollOptions) *LongPollSession[PlayerId] {
+	cfg.logger = cfg.logger.With("player", referenceID, "connection", nextConnectionID())
+	ctx, cancel := context.WithCancel(cfg.parent)
+	return &LongPollSession[PlayerId]{
+		id:          id,
+		referenceID: referenceID,
+		Messages:    messages,
+		notify:      make(chan struct{}),
+		lastPoll:    cfg.clock.Now(),
+		cfg:         cfg,
+		opts:        opts,
+		limiter:     newRateLimiter(cfg.rateLimit, cfg.clock.Now()),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *LongPollSession[PlayerId]) ReferenceID() PlayerId {
+	return s.referenceID
+}
+
+// Send queues a message for the player's next poll, dropping it if the player has too many unacknowledged messages.
+func (s *LongPollSession[PlayerId]) Send(message []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) >= s.opts.Buffer {
+		s.cfg.logger.Warn("send buffer full, dropping message", "func", "longpoll.Send")
+		s.cfg.metrics.SendDropped(s.cfg.room)
+		return
+	}
+	s.seq++
+	s.pending = append(s.pending, pendingMessage{seq: s.seq, data: message})
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Close disconnects the player and waits for the session to finish.
+func (s *LongPollSession[PlayerId]) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// watch disconnects the player when polls stop arriving.
+func (s *LongPollSession[PlayerId]) watch() {
+	sl := s.cfg.logger.With("func", "longpoll.watch")
+	sl.Debug("starting")
+	ticker := s.cfg.clock.NewTicker(s.opts.IdleTimeout / 4)
+	defer func() {
+		ticker.Stop()
+		s.cancel()
+		if s.onClose != nil {
+			s.onClose()
+		}
+		forward(s.cfg, s.Messages, SocketMessage[PlayerId]{ReferenceID: s.referenceID, Type: Disconnect})
+		close(s.done)
+		sl.Debug("watch exited")
+	}()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C():
+			s.mu.Lock()
+			idle := s.polling == 0 && s.cfg.clock.Now().Sub(s.lastPoll) > s.opts.IdleTimeout
+			s.mu.Unlock()
+			if idle {
+				sl.Debug("closing", "reason", "idle")
+				return
+			}
+		}
+	}
+}
+
+// poll discards the messages acknowledged by cursor and waits up to timeout for further messages, returning them
+// with the cursor that acknowledges them.
+func (s *LongPollSession[PlayerId]) poll(reqCtx context.Context, cursor uint64, timeout time.Duration) ([][]byte, uint64, error) {
+	s.mu.Lock()
+	s.polling++
+	s.ack(cursor)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.polling--
+		s.lastPoll = s.cfg.clock.Now()
+		s.mu.Unlock()
+	}()
+
+	timer := s.cfg.clock.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mu.Lock()
+		if len(s.pending) > 0 {
+			messages := make([][]byte, len(s.pending))
+			for i, m := range s.pending {
+				messages[i] = m.data
+			}
+			next := s.pending[len(s.pending)-1].seq
+			s.mu.Unlock()
+			return messages, next, nil
+		}
+		notify := s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-timer.C():
+			return nil, cursor, nil
+		case <-reqCtx.Done():
+			return nil, cursor, reqCtx.Err()
+		case <-s.ctx.Done():
+			return nil, cursor, ErrNotConnected
+		}
+	}
+}
+
+// ack discards pending messages up to and including cursor. The caller must hold s.mu.
+func (s *LongPollSession[PlayerId]) ack(cursor uint64) {
+	n := 0
+	for n < len(s.pending) && s.pending[n].seq <= cursor {
+		s.cfg.metrics.MessageSent(s.cfg.room, len(s.pending[n].data))
+		n++
+	}
+	s.pending = s.pending[n:]
+}
+
+// receive forwards a message sent by the player to the room, applying the room's limits.
+func (s *LongPollSession[PlayerId]) receive(msg []byte) error {
+	sl := s.cfg.logger.With("func", "longpoll.receive")
+	sl.Debug("message", s.cfg.payloads.attr(msg))
+	s.mu.Lock()
+	action, ok := s.cfg.throttle(sl, s.limiter, len(msg))
+	s.mu.Unlock()
+	if !ok {
+		switch action {
+		case RateLimitWarn:
+			if s.cfg.rateLimit.WarnMessage != nil {
+				s.Send(s.cfg.rateLimit.WarnMessage)
+			}
+		case RateLimitKick:
+			s.cancel()
+		}
+		return ErrRateLimited
+	}
+	select {
+	case <-s.ctx.Done():
+		return ErrNotConnected
+	default:
+	}
+	forward(s.cfg, s.Messages, inbound(s.ctx, s.cfg, s.referenceID, msg))
+	return nil
+}
@@ -0,0 +1,161 @@
+package goroom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupLongPoll(t *testing.T, opts Options[string], lpOpts LongPollOptions) (*Room[string, string], *httptest.Server) {
+	t.Helper()
+	room, err := NewRoom[string, string](context.Background(), "test-room-long-poll", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	lp, err := room.NewLongPoll(lpOpts)
+	if err != nil {
+		t.Fatalf("NewLongPoll returned error: %v", err)
+	}
+	go room.Start()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/join", func(w http.ResponseWriter, r *http.Request) {
+		lp.HandleJoinWithPlayer(r.URL.Query().Get("player"), sseErrorHandler)(w, r)
+	})
+	mux.HandleFunc("/poll", lp.HandlePoll(sseErrorHandler))
+	mux.HandleFunc("/send", lp.HandleSend(sseErrorHandler))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		room.Stop()
+		srv.Close()
+	})
+	return room, srv
+}
+
+func joinLongPoll(t *testing.T, srv *httptest.Server, player string) string {
+	t.Helper()
+	resp, err := http.Post(srv.URL+"/join?player="+player, "", nil)
+	if err != nil {
+		t.Fatalf("join returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	var join LongPollJoin
+	if err := json.NewDecoder(resp.Body).Decode(&join); err != nil {
+		t.Fatalf("invalid join response: %v", err)
+	}
+	return join.Session
+}
+
+func pollLongPoll(t *testing.T, srv *httptest.Server, session string, cursor uint64) (LongPollResponse, int) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + "/poll?session=" + session + "&cursor=" + strconv.FormatUint(cursor, 10))
+	if err != nil {
+		t.Fatalf("poll returned error: %v", err)
+	}
+	defer resp.Body.Close()
+	var poll LongPollResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&poll); err != nil {
+			t.Fatalf("invalid poll response: %v", err)
+		}
+	}
+	return poll, resp.StatusCode
+}
+
+func TestLongPoll(t *testing.T) {
+	t.Run("should deliver messages until acknowledged", func(t *testing.T) {
+		room, srv := setupLongPoll(t, Options[string]{}, LongPollOptions{PollTimeout: 50 * time.Millisecond})
+		session := joinLongPoll(t, srv, "player1")
+
+		room.SendMessageToPlayer("player1", []byte("one"))
+		room.SendMessageToAllPlayers([]byte("two"))
+		poll, _ := pollLongPoll(t, srv, session, 0)
+		if len(poll.Messages) != 2 || string(poll.Messages[0]) != "one" || poll.Cursor != 2 {
+			t.Fatalf("unexpected poll: %+v", poll)
+		}
+		// Polling with the old cursor redelivers, as the response may have been lost.
+		if again, _ := pollLongPoll(t, srv, session, 0); len(again.Messages) != 2 {
+			t.Errorf("expected unacknowledged messages to be redelivered, got %+v", again)
+		}
+		if empty, _ := pollLongPoll(t, srv, session, poll.Cursor); len(empty.Messages) != 0 || empty.Cursor != 2 {
+			t.Errorf("expected an empty poll after the timeout, got %+v", empty)
+		}
+	})
+	t.Run("should wake a waiting poll when a message is sent", func(t *testing.T) {
+		room, srv := setupLongPoll(t, Options[string]{}, LongPollOptions{PollTimeout: 5 * time.Second, IdleTimeout: 10 * time.Second})
+		session := joinLongPoll(t, srv, "player1")
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			room.SendMessageToPlayer("player1", []byte("wake"))
+		}()
+		start := time.Now()
+		poll, _ := pollLongPoll(t, srv, session, 0)
+		if len(poll.Messages) != 1 || string(poll.Messages[0]) != "wake" {
+			t.Errorf("unexpected poll: %+v", poll)
+		}
+		if time.Since(start) > time.Second {
+			t.Error("expected the poll to return as soon as the message was sent")
+		}
+	})
+	t.Run("should forward sent messages to the room", func(t *testing.T) {
+		received := make(chan string, 1)
+		_, srv := setupLongPoll(t, Options[string]{OnMessage: func(player string, message []byte) {
+			received <- player + ":" + string(message)
+		}}, LongPollOptions{})
+		session := joinLongPoll(t, srv, "player1")
+		resp, err := http.Post(srv.URL+"/send?session="+session, "text/plain", strings.NewReader("move"))
+		if err != nil {
+			t.Fatalf("send returned error: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d", resp.StatusCode)
+		}
+		select {
+		case got := <-received:
+			if got != "player1:move" {
+				t.Errorf("unexpected message: %s", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnMessage")
+		}
+		if _, code := pollLongPoll(t, srv, "unknown", 0); code != http.StatusConflict {
+			t.Errorf("expected 409 for an unknown session, got %d", code)
+		}
+	})
+	t.Run("should disconnect the player when polls stop", func(t *testing.T) {
+		disconnected := make(chan string, 1)
+		room, srv := setupLongPoll(t, Options[string]{OnDisconnect: func(player string) { disconnected <- player }},
+			LongPollOptions{PollTimeout: 20 * time.Millisecond, IdleTimeout: 80 * time.Millisecond})
+		session := joinLongPoll(t, srv, "player1")
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnDisconnect")
+		}
+		if room.GetPlayerPresence("player1").IsConnected {
+			t.Error("expected player1 to be disconnected")
+		}
+		if _, code := pollLongPoll(t, srv, session, 0); code != http.StatusConflict {
+			t.Errorf("expected 409 for a closed session, got %d", code)
+		}
+		if session2 := joinLongPoll(t, srv, "player1"); session2 == session {
+			t.Error("expected a new session ID on rejoin")
+		}
+	})
+	t.Run("should reject inconsistent options", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-long-poll", Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		_, err = room.NewLongPoll(LongPollOptions{PollTimeout: time.Minute, IdleTimeout: time.Second})
+		if !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("expected ErrInvalidOptions, got %v", err)
+		}
+	})
+}
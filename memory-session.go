@@ -0,0 +1,157 @@
+package goroom
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryClient is the player's end of an in-process connection to a room, made with ConnectMemory. It is mainly
+// intended for tests; see the goroomtest package.
+type MemoryClient[PlayerId comparable] struct {
+	s *memorySession[PlayerId]
+}
+
+// ConnectMemory admits the player to the room over an in-process connection, with the same rules and callbacks as
+// HandleSocketWithPlayer. The context's values are available to the Context callbacks.
+func (room *Room[RoomId, PlayerId]) ConnectMemory(ctx context.Context, playerID PlayerId) (*MemoryClient[PlayerId], error) {
+	ctx, span := room.tracer.Start(ctx, "goroom.connect",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.player", playerID),
+		Attr("goroom.transport", "memory"),
+	)
+	defer span.End()
+
+	var zero PlayerId
+	if playerID == zero {
+		span.RecordError(ErrNilPlayerID)
+		return nil, ErrNilPlayerID
+	}
+	if !room.CanJoin(playerID) {
+		span.RecordError(ErrCannotJoin)
+		return nil, ErrCannotJoin
+	}
+	room.Slogger.Info("new memory connection", "player", playerID)
+	ss, err := room.admit(ctx, playerID, func(cfg sessionConfig) SocketSessioner[PlayerId] {
+		return newMemorySession[PlayerId](playerID, room.messages, cfg)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	return &MemoryClient[PlayerId]{s: ss.(*memorySession[PlayerId])}, nil
+}
+
+// Receive returns the channel of messages sent to the player. It is closed when the connection closes.
+func (c *MemoryClient[PlayerId]) Receive() <-chan []byte {
+	return c.s.toClient
+}
+
+// Send sends a message to the room as the player, applying the room's limits. It returns ErrNotConnected once the
+// connection has closed, and ErrMessageTooLarge or ErrRateLimited if the message is rejected.
+func (c *MemoryClient[PlayerId]) Send(message []byte) error {
+	return c.s.receive(message)
+}
+
+// Close disconnects the player, as if their connection had dropped.
+func (c *MemoryClient[PlayerId]) Close() {
+	c.s.Close()
+}
+
+// Done is closed when the connection closes from either end.
+func (c *MemoryClient[PlayerId]) Done() <-chan struct{} {
+	return c.s.ctx.Done()
+}
+
+type memorySession[PlayerId comparable] struct {
+	referenceID PlayerId
+
+	// The message bit
+	sendMu   sync.Mutex
+	toClient chan []byte
+	Messages chan SocketMessage[PlayerId]
+
+	// The limits bit
+	cfg       sessionConfig
+	limiterMu sync.Mutex
+	limiter   *rateLimiter
+
+	// The concurrency bit
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+func newMemorySession[PlayerId comparable](referenceID PlayerId, messages chan SocketMessage[PlayerId], cfg sessionConfig) *memorySession[PlayerId] {
+	cfg.logger = cfg.logger.With("player", referenceID, "connection", nextConnectionID())
+	ctx, cancel := context.WithCancel(cfg.parent)
+	s := &memorySession[PlayerId]{
+		referenceID: referenceID,
+		toClient:    make(chan []byte, 255),
+		Messages:    messages,
+		cfg:         cfg,
+		limiter:     newRateLimiter(cfg.rateLimit, cfg.clock.Now()),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	// The player's context is cancelled when the room stops.
+	context.AfterFunc(ctx, s.Close)
+	return s
+}
+
+func (s *memorySession[PlayerId]) ReferenceID() PlayerId {
+	return s.referenceID
+}
+
+// Send delivers a message to the client, dropping it if the client's buffer is full.
+func (s *memorySession[PlayerId]) Send(message []byte) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.ctx.Err() != nil {
+		return
+	}
+	select {
+	case s.toClient <- message:
+		s.cfg.metrics.MessageSent(s.cfg.room, len(message))
+	default:
+		s.cfg.logger.Warn("send buffer full, dropping message", "func", "memory.Send")
+		s.cfg.metrics.SendDropped(s.cfg.room)
+	}
+}
+
+func (s *memorySession[PlayerId]) Close() {
+	s.closeOnce.Do(func() {
+		s.sendMu.Lock()
+		s.cancel()
+		close(s.toClient)
+		s.sendMu.Unlock()
+		s.cfg.logger.Debug("closed", "func", "memory.Close")
+		forward(s.cfg, s.Messages, SocketMessage[PlayerId]{ReferenceID: s.referenceID, Type: Disconnect})
+	})
+}
+
+func (s *memorySession[PlayerId]) receive(msg []byte) error {
+	if s.ctx.Err() != nil {
+		return ErrNotConnected
+	}
+	if s.cfg.maxMessageSize > 0 && int64(len(msg)) > s.cfg.maxMessageSize {
+		return ErrMessageTooLarge
+	}
+	sl := s.cfg.logger.With("func", "memory.receive")
+	sl.Debug("message", s.cfg.payloads.attr(msg))
+	s.limiterMu.Lock()
+	action, ok := s.cfg.throttle(sl, s.limiter, len(msg))
+	s.limiterMu.Unlock()
+	if !ok {
+		switch action {
+		case RateLimitWarn:
+			if s.cfg.rateLimit.WarnMessage != nil {
+				s.Send(s.cfg.rateLimit.WarnMessage)
+			}
+		case RateLimitKick:
+			s.Close()
+		}
+		return ErrRateLimited
+	}
+	forward(s.cfg, s.Messages, inbound(s.ctx, s.cfg, s.referenceID, msg))
+	return nil
+}
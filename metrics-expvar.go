@@ -0,0 +1,90 @@
+package goroom
+
+import (
+	"expvar"
+	"sync"
+)
+
+// ExpvarMetrics implements Metrics by publishing counters with the expvar package. Per-room counters are kept in a
+// nested map under "rooms", keyed by room ID, and removed when the room stops.
+type ExpvarMetrics struct {
+	root  *expvar.Map
+	rooms *expvar.Map
+	mu    sync.Mutex
+}
+
+// NewExpvarMetrics publishes the metrics under name. Like expvar.Publish it panics if name is already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		root:  expvar.NewMap(name),
+		rooms: new(expvar.Map),
+	}
+	m.root.Set("rooms", m.rooms)
+	return m
+}
+
+func (m *ExpvarMetrics) room(room string) *expvar.Map {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.rooms.Get(room).(*expvar.Map); ok {
+		return v
+	}
+	v := new(expvar.Map)
+	m.rooms.Set(room, v)
+	return v
+}
+
+func (m *ExpvarMetrics) add(room, key string, delta int64) {
+	m.root.Add(key, delta)
+	m.room(room).Add(key, delta)
+}
+
+func (m *ExpvarMetrics) RoomStarted(string) {
+	m.root.Add("rooms_active", 1)
+}
+
+func (m *ExpvarMetrics) RoomStopped(room string) {
+	m.root.Add("rooms_active", -1)
+	m.mu.Lock()
+	m.rooms.Delete(room)
+	m.mu.Unlock()
+}
+
+func (m *ExpvarMetrics) PlayerConnected(room string) {
+	m.add(room, "player_connects", 1)
+	m.add(room, "players_connected", 1)
+}
+
+func (m *ExpvarMetrics) PlayerDisconnected(room string) {
+	m.add(room, "player_disconnects", 1)
+	m.add(room, "players_connected", -1)
+}
+
+func (m *ExpvarMetrics) PlayerRemoved(room string) {
+	m.add(room, "player_removals", 1)
+}
+
+func (m *ExpvarMetrics) MessageReceived(room string, size int) {
+	m.add(room, "messages_received", 1)
+	m.add(room, "received_bytes", int64(size))
+}
+
+func (m *ExpvarMetrics) MessageSent(room string, size int) {
+	m.add(room, "messages_sent", 1)
+	m.add(room, "sent_bytes", int64(size))
+}
+
+func (m *ExpvarMetrics) SendDropped(room string) {
+	m.add(room, "sends_dropped", 1)
+}
+
+func (m *ExpvarMetrics) MessageThrottled(room string, size int) {
+	m.add(room, "messages_throttled", 1)
+	m.add(room, "throttled_bytes", int64(size))
+}
+
+func (m *ExpvarMetrics) QueueDepth(room string, depth int) {
+	v := new(expvar.Int)
+	v.Set(int64(depth))
+	m.room(room).Set("message_queue_depth", v)
+}
@@ -0,0 +1,178 @@
+package goroom
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PrometheusMetrics implements Metrics and serves the values in the Prometheus text exposition format.
+type PrometheusMetrics struct {
+	perRoom bool
+
+	mu          sync.Mutex
+	roomsActive int64
+	series      map[string]*roomSeries
+}
+
+type roomSeries struct {
+	playersConnected  int64
+	connects          uint64
+	disconnects       uint64
+	removals          uint64
+	messagesReceived  uint64
+	bytesReceived     uint64
+	messagesSent      uint64
+	bytesSent         uint64
+	sendsDropped      uint64
+	messagesThrottled uint64
+	bytesThrottled    uint64
+	queueDepth        int64
+}
+
+// NewPrometheusMetrics creates a PrometheusMetrics. With perRoom set every series carries a room label and the
+// series for a room are dropped when it stops; otherwise all rooms are aggregated, which suits servers with many
+// short-lived rooms.
+func NewPrometheusMetrics(perRoom bool) *PrometheusMetrics {
+	return &PrometheusMetrics{
+		perRoom: perRoom,
+		series:  make(map[string]*roomSeries),
+	}
+}
+
+func (p *PrometheusMetrics) update(room string, fn func(s *roomSeries)) {
+	if !p.perRoom {
+		room = ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.series[room]
+	if !ok {
+		s = &roomSeries{}
+		p.series[room] = s
+	}
+	fn(s)
+}
+
+func (p *PrometheusMetrics) RoomStarted(string) {
+	p.mu.Lock()
+	p.roomsActive++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusMetrics) RoomStopped(room string) {
+	p.mu.Lock()
+	p.roomsActive--
+	if p.perRoom {
+		delete(p.series, room)
+	}
+	p.mu.Unlock()
+}
+
+func (p *PrometheusMetrics) PlayerConnected(room string) {
+	p.update(room, func(s *roomSeries) {
+		s.connects++
+		s.playersConnected++
+	})
+}
+
+func (p *PrometheusMetrics) PlayerDisconnected(room string) {
+	p.update(room, func(s *roomSeries) {
+		s.disconnects++
+		s.playersConnected--
+	})
+}
+
+func (p *PrometheusMetrics) PlayerRemoved(room string) {
+	p.update(room, func(s *roomSeries) { s.removals++ })
+}
+
+func (p *PrometheusMetrics) MessageReceived(room string, size int) {
+	p.update(room, func(s *roomSeries) {
+		s.messagesReceived++
+		s.bytesReceived += uint64(size)
+	})
+}
+
+func (p *PrometheusMetrics) MessageSent(room string, size int) {
+	p.update(room, func(s *roomSeries) {
+		s.messagesSent++
+		s.bytesSent += uint64(size)
+	})
+}
+
+func (p *PrometheusMetrics) SendDropped(room string) {
+	p.update(room, func(s *roomSeries) { s.sendsDropped++ })
+}
+
+func (p *PrometheusMetrics) MessageThrottled(room string, size int) {
+	p.update(room, func(s *roomSeries) {
+		s.messagesThrottled++
+		s.bytesThrottled += uint64(size)
+	})
+}
+
+func (p *PrometheusMetrics) QueueDepth(room string, depth int) {
+	p.update(room, func(s *roomSeries) { s.queueDepth = int64(depth) })
+}
+
+type promFamily struct {
+	name  string
+	kind  string
+	help  string
+	value func(s *roomSeries) any
+}
+
+var promFamilies = []promFamily{
+	{"goroom_players_connected", "gauge", "Players with an open connection.", func(s *roomSeries) any { return s.playersConnected }},
+	{"goroom_player_connects_total", "counter", "Player connections admitted.", func(s *roomSeries) any { return s.connects }},
+	{"goroom_player_disconnects_total", "counter", "Player connections closed.", func(s *roomSeries) any { return s.disconnects }},
+	{"goroom_player_removals_total", "counter", "Players removed from rooms.", func(s *roomSeries) any { return s.removals }},
+	{"goroom_messages_received_total", "counter", "Inbound messages accepted from players.", func(s *roomSeries) any { return s.messagesReceived }},
+	{"goroom_received_bytes_total", "counter", "Bytes of inbound messages accepted from players.", func(s *roomSeries) any { return s.bytesReceived }},
+	{"goroom_messages_sent_total", "counter", "Outbound messages written to players.", func(s *roomSeries) any { return s.messagesSent }},
+	{"goroom_sent_bytes_total", "counter", "Bytes of outbound messages written to players.", func(s *roomSeries) any { return s.bytesSent }},
+	{"goroom_sends_dropped_total", "counter", "Outbound messages dropped because a session's send buffer was full or it had closed.", func(s *roomSeries) any { return s.sendsDropped }},
+	{"goroom_messages_throttled_total", "counter", "Inbound messages rejected by the rate limit.", func(s *roomSeries) any { return s.messagesThrottled }},
+	{"goroom_throttled_bytes_total", "counter", "Bytes of inbound messages rejected by the rate limit.", func(s *roomSeries) any { return s.bytesThrottled }},
+	{"goroom_message_queue_depth", "gauge", "Messages waiting to be processed by the room.", func(s *roomSeries) any { return s.queueDepth }},
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	p.mu.Lock()
+	fmt.Fprintf(&sb, "# HELP goroom_rooms_active Rooms currently started.\n# TYPE goroom_rooms_active gauge\ngoroom_rooms_active %d\n", p.roomsActive)
+	rooms := make([]string, 0, len(p.series))
+	for room := range p.series {
+		rooms = append(rooms, room)
+	}
+	slices.Sort(rooms)
+	for _, f := range promFamilies {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, room := range rooms {
+			if p.perRoom {
+				fmt.Fprintf(&sb, "%s{room=\"%s\"} %v\n", f.name, escapeLabelValue(room), f.value(p.series[room]))
+			} else {
+				fmt.Fprintf(&sb, "%s %v\n", f.name, f.value(p.series[room]))
+			}
+		}
+	}
+	p.mu.Unlock()
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
@@ -0,0 +1,99 @@
+package goroom
+
+import "sync"
+
+// Metrics receives counters and gauges reported by rooms and their sessions. The room argument is the room ID
+// formatted with fmt.Sprint. Implementations must be safe for concurrent use.
+type Metrics interface {
+	RoomStarted(room string)
+	RoomStopped(room string)
+
+	PlayerConnected(room string)
+	PlayerDisconnected(room string)
+	PlayerRemoved(room string)
+
+	MessageReceived(room string, size int)
+	MessageSent(room string, size int)
+	SendDropped(room string)
+	MessageThrottled(room string, size int)
+
+	// QueueDepth reports the number of messages waiting to be processed by the room.
+	QueueDepth(room string, depth int)
+}
+
+// NopMetrics discards everything. It is the default when Options.Metrics is nil.
+type NopMetrics struct{}
+
+func (NopMetrics) RoomStarted(string)           {}
+func (NopMetrics) RoomStopped(string)           {}
+func (NopMetrics) PlayerConnected(string)       {}
+func (NopMetrics) PlayerDisconnected(string)    {}
+func (NopMetrics) PlayerRemoved(string)         {}
+func (NopMetrics) MessageReceived(string, int)  {}
+func (NopMetrics) MessageSent(string, int)      {}
+func (NopMetrics) SendDropped(string)           {}
+func (NopMetrics) MessageThrottled(string, int) {}
+func (NopMetrics) QueueDepth(string, int)       {}
+
+// roomMetrics passes a room's reports on to its Metrics until the room has stopped, so that late reports from
+// closing sessions cannot recreate the series RoomStopped removed.
+type roomMetrics struct {
+	Metrics
+	mu      sync.RWMutex
+	stopped bool
+}
+
+func (m *roomMetrics) RoomStopped(room string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+	m.Metrics.RoomStopped(room)
+}
+
+// stop drops later reports from a room that stopped without starting, and so never reports RoomStopped.
+func (m *roomMetrics) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+}
+
+// report passes a report on unless the room has stopped.
+func (m *roomMetrics) report(fn func(metrics Metrics)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.stopped {
+		fn(m.Metrics)
+	}
+}
+
+func (m *roomMetrics) PlayerConnected(room string) {
+	m.report(func(metrics Metrics) { metrics.PlayerConnected(room) })
+}
+
+func (m *roomMetrics) PlayerDisconnected(room string) {
+	m.report(func(metrics Metrics) { metrics.PlayerDisconnected(room) })
+}
+
+func (m *roomMetrics) PlayerRemoved(room string) {
+	m.report(func(metrics Metrics) { metrics.PlayerRemoved(room) })
+}
+
+func (m *roomMetrics) MessageReceived(room string, size int) {
+	m.report(func(metrics Metrics) { metrics.MessageReceived(room, size) })
+}
+
+func (m *roomMetrics) MessageSent(room string, size int) {
+	m.report(func(metrics Metrics) { metrics.MessageSent(room, size) })
+}
+
+func (m *roomMetrics) SendDropped(room string) {
+	m.report(func(metrics Metrics) { metrics.SendDropped(room) })
+}
+
+func (m *roomMetrics) MessageThrottled(room string, size int) {
+	m.report(func(metrics Metrics) { metrics.MessageThrottled(room, size) })
+}
+
+func (m *roomMetrics) QueueDepth(room string, depth int) {
+	m.report(func(metrics Metrics) { metrics.QueueDepth(room, depth) })
+}
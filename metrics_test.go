@@ -0,0 +1,258 @@
+package goroom
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws/wsutil"
+)
+
+// recordingMetrics counts calls per method for assertions.
+type recordingMetrics struct {
+	NopMetrics
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{calls: make(map[string]int)}
+}
+
+func (m *recordingMetrics) record(name string) {
+	m.mu.Lock()
+	m.calls[name]++
+	m.mu.Unlock()
+}
+
+func (m *recordingMetrics) count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[name]
+}
+
+func (m *recordingMetrics) PlayerDisconnected(string)    { m.record("PlayerDisconnected") }
+func (m *recordingMetrics) PlayerRemoved(string)         { m.record("PlayerRemoved") }
+func (m *recordingMetrics) MessageReceived(string, int)  { m.record("MessageReceived") }
+func (m *recordingMetrics) MessageSent(string, int)      { m.record("MessageSent") }
+func (m *recordingMetrics) SendDropped(string)           { m.record("SendDropped") }
+func (m *recordingMetrics) MessageThrottled(string, int) { m.record("MessageThrottled") }
+func (m *recordingMetrics) QueueDepth(string, int)       { m.record("QueueDepth") }
+
+func TestSocketSession_Metrics(t *testing.T) {
+	t.Run("should report received, sent and dropped messages", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		serverConn, clientConn := net.Pipe()
+		messages := make(chan SocketMessage[string], 10)
+		session := newSocketSession(serverConn, "player1", messages, sessionConfig{metrics: metrics, room: "room"})
+		t.Cleanup(func() {
+			session.Close()
+			_ = clientConn.Close()
+		})
+
+		if err := wsutil.WriteClientBinary(clientConn, []byte("hello")); err != nil {
+			t.Fatalf("Failed to write client message: %v", err)
+		}
+		<-messages
+		if metrics.count("MessageReceived") != 1 {
+			t.Errorf("expected 1 received message, got %d", metrics.count("MessageReceived"))
+		}
+
+		session.Send([]byte("hello back"))
+		if _, _, err := wsutil.ReadServerData(clientConn); err != nil {
+			t.Fatalf("Failed to read server data: %v", err)
+		}
+		// The write is reported after it completes, so allow the WriteLoop to catch up.
+		time.Sleep(10 * time.Millisecond)
+		if metrics.count("MessageSent") != 1 {
+			t.Errorf("expected 1 sent message, got %d", metrics.count("MessageSent"))
+		}
+	})
+	t.Run("should wait for room in the send buffer and drop sends once closed", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		ctx, cancel := context.WithCancel(context.Background())
+		session := &SocketSession[string]{
+			referenceID: "player1",
+			send:        make(chan []byte, 1),
+			cfg:         sessionConfig{metrics: metrics, logger: slog.Default()},
+			ctx:         ctx,
+			cancel:      cancel,
+		}
+		session.Send([]byte("one"))
+		sent := make(chan struct{})
+		go func() {
+			session.Send([]byte("two"))
+			close(sent)
+		}()
+		select {
+		case <-sent:
+			t.Fatal("expected Send to wait while the buffer is full")
+		case <-time.After(20 * time.Millisecond):
+		}
+		if got := <-session.send; string(got) != "one" {
+			t.Errorf("expected 'one', got '%s'", got)
+		}
+		<-sent
+		if got := <-session.send; string(got) != "two" {
+			t.Errorf("expected 'two', got '%s'", got)
+		}
+		if metrics.count("SendDropped") != 0 {
+			t.Errorf("expected no dropped sends, got %d", metrics.count("SendDropped"))
+		}
+
+		session.Send([]byte("three"))
+		cancel()
+		session.Send([]byte("four"))
+		if metrics.count("SendDropped") != 1 {
+			t.Errorf("expected 1 dropped send, got %d", metrics.count("SendDropped"))
+		}
+	})
+}
+
+func TestRoom_Metrics(t *testing.T) {
+	t.Run("should report removals and queue depth", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		room, err := NewRoom[string, string](context.Background(), "test-room-metrics", Options[string]{Metrics: metrics})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		defer room.Stop()
+
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Disconnect}
+		time.Sleep(10 * time.Millisecond)
+		room.SetStatus(Locked)
+
+		if metrics.count("PlayerDisconnected") != 1 {
+			t.Errorf("expected 1 disconnect, got %d", metrics.count("PlayerDisconnected"))
+		}
+		if metrics.count("QueueDepth") != 1 {
+			t.Errorf("expected queue depth to be reported once, got %d", metrics.count("QueueDepth"))
+		}
+		if metrics.count("PlayerRemoved") != 1 {
+			t.Errorf("expected 1 removal, got %d", metrics.count("PlayerRemoved"))
+		}
+	})
+}
+
+func TestRoom_Metrics_Stop(t *testing.T) {
+	for _, perRoom := range []bool{false, true} {
+		t.Run("should return the connected gauge to zero when stopped, per room "+strconv.FormatBool(perRoom), func(t *testing.T) {
+			p := NewPrometheusMetrics(perRoom)
+			room, err := NewRoom[string, string](context.Background(), "lobby", Options[string]{Metrics: p})
+			if err != nil {
+				t.Fatalf("NewRoom returned error: %v", err)
+			}
+			go room.Start()
+			eventually(t, "the room to start", func() bool {
+				room.mu.RLock()
+				defer room.mu.RUnlock()
+				return room.isStarted
+			})
+			for _, player := range []string{"alice", "bob"} {
+				if _, err := room.ConnectMemory(context.Background(), player); err != nil {
+					t.Fatal(err)
+				}
+			}
+			room.Stop()
+			room.Wait()
+			// A session reporting after the room stopped must not bring its series back.
+			room.metrics.MessageSent(room.metricsLabel, 1)
+
+			var sb strings.Builder
+			_, _ = p.WriteTo(&sb)
+			if perRoom {
+				if strings.Contains(sb.String(), `room="lobby"`) {
+					t.Errorf("expected lobby series to stay dropped, got\n%s", sb.String())
+				}
+			} else if !strings.Contains(sb.String(), "\ngoroom_players_connected 0\n") {
+				t.Errorf("expected no players connected, got\n%s", sb.String())
+			}
+		})
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	t.Run("should expose per-room series", func(t *testing.T) {
+		p := NewPrometheusMetrics(true)
+		p.RoomStarted("lobby")
+		p.PlayerConnected("lobby")
+		p.MessageReceived("lobby", 5)
+		p.MessageReceived("lobby", 7)
+		p.QueueDepth(`odd"room`, 3)
+
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		body := rec.Body.String()
+
+		for _, want := range []string{
+			"# TYPE goroom_rooms_active gauge\ngoroom_rooms_active 1\n",
+			"# TYPE goroom_messages_received_total counter\n",
+			`goroom_players_connected{room="lobby"} 1`,
+			`goroom_messages_received_total{room="lobby"} 2`,
+			`goroom_received_bytes_total{room="lobby"} 12`,
+			`goroom_message_queue_depth{room="odd\"room"} 3`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected output to contain %q, got\n%s", want, body)
+			}
+		}
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+			t.Errorf("unexpected content type %s", rec.Header().Get("Content-Type"))
+		}
+
+		p.RoomStopped("lobby")
+		var sb strings.Builder
+		_, _ = p.WriteTo(&sb)
+		if strings.Contains(sb.String(), `room="lobby"`) {
+			t.Errorf("expected lobby series to be dropped after stop, got\n%s", sb.String())
+		}
+	})
+	t.Run("should aggregate rooms without labels", func(t *testing.T) {
+		p := NewPrometheusMetrics(false)
+		p.SendDropped("a")
+		p.SendDropped("b")
+
+		var sb strings.Builder
+		_, _ = p.WriteTo(&sb)
+		if !strings.Contains(sb.String(), "\ngoroom_sends_dropped_total 2\n") {
+			t.Errorf("expected aggregated series, got\n%s", sb.String())
+		}
+		if strings.Contains(sb.String(), "room=") {
+			t.Errorf("expected no room labels, got\n%s", sb.String())
+		}
+	})
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	t.Run("should publish totals and per-room counters", func(t *testing.T) {
+		// expvar names are global, so make the name unique for repeated runs.
+		name := "goroom_test_metrics_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		m := NewExpvarMetrics(name)
+		m.RoomStarted("lobby")
+		m.MessageSent("lobby", 4)
+		m.MessageSent("lobby", 6)
+
+		var got struct {
+			RoomsActive int64 `json:"rooms_active"`
+			SentBytes   int64 `json:"sent_bytes"`
+			Rooms       map[string]struct {
+				MessagesSent int64 `json:"messages_sent"`
+			} `json:"rooms"`
+		}
+		if err := json.Unmarshal([]byte(expvar.Get(name).String()), &got); err != nil {
+			t.Fatalf("failed to decode expvar output: %v", err)
+		}
+		if got.RoomsActive != 1 || got.SentBytes != 10 || got.Rooms["lobby"].MessagesSent != 2 {
+			t.Errorf("unexpected expvar values %+v", got)
+		}
+	})
+}
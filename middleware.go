@@ -0,0 +1,142 @@
+package goroom
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// InboundMessage is a message travelling through the middleware chain between a session and Options.OnMessage.
+// Middlewares may rewrite Message, or set Decoded for later handlers. Context carries the message's trace span.
+type InboundMessage[RoomId comparable, PlayerID comparable] struct {
+	Context context.Context
+	Room    *Room[RoomId, PlayerID]
+	Player  PlayerID
+	Message []byte
+	Decoded any
+}
+
+type Handler[RoomId comparable, PlayerID comparable] func(msg *InboundMessage[RoomId, PlayerID])
+
+// Middleware wraps a Handler. A middleware drops or short-circuits a message by not calling next.
+type Middleware[RoomId comparable, PlayerID comparable] func(next Handler[RoomId, PlayerID]) Handler[RoomId, PlayerID]
+
+// Use appends middlewares to the room's inbound chain. The first middleware added is the outermost.
+func (room *Room[RoomId, PlayerID]) Use(middlewares ...Middleware[RoomId, PlayerID]) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.middlewares = append(room.middlewares, middlewares...)
+
+	handler := room.dispatchMessage
+	for i := len(room.middlewares) - 1; i >= 0; i-- {
+		handler = room.middlewares[i](handler)
+	}
+	room.handler = handler
+}
+
+// LoggingMiddleware logs every inbound message at the given level once it has been handled.
+func LoggingMiddleware[RoomId comparable, PlayerID comparable](logger *slog.Logger, level slog.Level) Middleware[RoomId, PlayerID] {
+	return func(next Handler[RoomId, PlayerID]) Handler[RoomId, PlayerID] {
+		return func(msg *InboundMessage[RoomId, PlayerID]) {
+			start := time.Now()
+			next(msg)
+			logger.Log(msg.Context, level, "inbound message",
+				"room", msg.Room.ID,
+				"player", msg.Player,
+				"size", len(msg.Message),
+				"duration", time.Since(start),
+			)
+		}
+	}
+}
+
+// RecoverMiddleware recovers panics raised further down the chain and passes them to onPanic. If onPanic is nil
+// the panic is logged with the room's logger.
+func RecoverMiddleware[RoomId comparable, PlayerID comparable](onPanic func(msg *InboundMessage[RoomId, PlayerID], recovered any, stack []byte)) Middleware[RoomId, PlayerID] {
+	return func(next Handler[RoomId, PlayerID]) Handler[RoomId, PlayerID] {
+		return func(msg *InboundMessage[RoomId, PlayerID]) {
+			defer func() {
+				if r := recover(); r != nil {
+					stack := debug.Stack()
+					if onPanic != nil {
+						onPanic(msg, r, stack)
+						return
+					}
+					msg.Room.Slogger.Error("panic handling message", "player", msg.Player, "panic", fmt.Sprint(r), "stack", string(stack))
+				}
+			}()
+			next(msg)
+		}
+	}
+}
+
+// DecodeMiddleware unmarshals each message into a T using codec and stores it in InboundMessage.Decoded, which
+// OnMessageContext can read with DecodedMessage. Messages that fail to decode are dropped and the player is sent
+// the room's error reply.
+func DecodeMiddleware[RoomId comparable, PlayerID comparable, T any](codec Codec) Middleware[RoomId, PlayerID] {
+	return func(next Handler[RoomId, PlayerID]) Handler[RoomId, PlayerID] {
+		return func(msg *InboundMessage[RoomId, PlayerID]) {
+			var v T
+			if err := codec.Unmarshal(msg.Message, &v); err != nil {
+				msg.Room.Slogger.Debug("message decode failed", "player", msg.Player, "err", err)
+				msg.Room.SendMessageToPlayerContext(msg.Context, msg.Player, msg.Room.errorReply(err))
+				return
+			}
+			msg.Decoded = v
+			next(msg)
+		}
+	}
+}
+
+type decodedKey struct{}
+
+// DecodedMessage returns the value a middleware, such as DecodeMiddleware, stored in InboundMessage.Decoded for the
+// message passed to OnMessageContext with ctx. It reports false if the message was not decoded into a T.
+func DecodedMessage[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(decodedKey{}).(T)
+	return v, ok
+}
+
+// MessageMetrics holds the counters updated by MetricsMiddleware.
+type MessageMetrics struct {
+	messages atomic.Uint64
+	bytes    atomic.Uint64
+	inFlight atomic.Int64
+	duration atomic.Int64
+}
+
+type MessageMetricsSnapshot struct {
+	Messages      uint64
+	Bytes         uint64
+	InFlight      int64
+	TotalDuration time.Duration
+}
+
+func (m *MessageMetrics) Snapshot() MessageMetricsSnapshot {
+	return MessageMetricsSnapshot{
+		Messages:      m.messages.Load(),
+		Bytes:         m.bytes.Load(),
+		InFlight:      m.inFlight.Load(),
+		TotalDuration: time.Duration(m.duration.Load()),
+	}
+}
+
+// MetricsMiddleware counts messages, bytes and the time spent handling them.
+func MetricsMiddleware[RoomId comparable, PlayerID comparable](m *MessageMetrics) Middleware[RoomId, PlayerID] {
+	return func(next Handler[RoomId, PlayerID]) Handler[RoomId, PlayerID] {
+		return func(msg *InboundMessage[RoomId, PlayerID]) {
+			m.messages.Add(1)
+			m.bytes.Add(uint64(len(msg.Message)))
+			m.inFlight.Add(1)
+			start := time.Now()
+			defer func() {
+				m.duration.Add(int64(time.Since(start)))
+				m.inFlight.Add(-1)
+			}()
+			next(msg)
+		}
+	}
+}
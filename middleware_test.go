@@ -0,0 +1,171 @@
+package goroom
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRoom_Use(t *testing.T) {
+	t.Run("should run middlewares in order before OnMessage", func(t *testing.T) {
+		room, handler, cleanup := setupTestRoom[string](t, "test-room-middleware")
+		defer cleanup()
+
+		var order []string
+		tag := func(name string) Middleware[string, string] {
+			return func(next Handler[string, string]) Handler[string, string] {
+				return func(msg *InboundMessage[string, string]) {
+					order = append(order, name)
+					msg.Message = append(msg.Message, []byte(name)...)
+					next(msg)
+				}
+			}
+		}
+		room.Use(tag("a"), tag("b"))
+		room.Use(tag("c"))
+
+		room.handleMessage(context.Background(), "player-1", []byte("msg-"))
+
+		if strings.Join(order, "") != "abc" {
+			t.Errorf("expected middlewares to run in order abc, got %v", order)
+		}
+		msgResults := handler.GetOnMessageResults()
+		if len(msgResults) != 1 || string(msgResults[0].Message) != "msg-abc" {
+			t.Errorf("expected transformed message 'msg-abc', got %v", msgResults)
+		}
+	})
+	t.Run("should drop messages when next is not called", func(t *testing.T) {
+		room, handler, cleanup := setupTestRoom[string](t, "test-room-middleware")
+		defer cleanup()
+
+		room.Use(func(next Handler[string, string]) Handler[string, string] {
+			return func(msg *InboundMessage[string, string]) {
+				if msg.Player == "muted" {
+					return
+				}
+				next(msg)
+			}
+		})
+
+		room.handleMessage(context.Background(), "muted", []byte("hello"))
+		room.handleMessage(context.Background(), "player-1", []byte("hello"))
+
+		msgResults := handler.GetOnMessageResults()
+		if len(msgResults) != 1 || msgResults[0].ReferenceID != "player-1" {
+			t.Errorf("expected only player-1's message, got %v", msgResults)
+		}
+	})
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	t.Run("should recover panics from later handlers", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-recover")
+		defer cleanup()
+
+		var recovered any
+		room.Use(
+			RecoverMiddleware(func(msg *InboundMessage[string, string], r any, stack []byte) {
+				recovered = r
+			}),
+			func(next Handler[string, string]) Handler[string, string] {
+				return func(msg *InboundMessage[string, string]) {
+					panic("boom")
+				}
+			},
+		)
+
+		room.handleMessage(context.Background(), "player-1", []byte("hello"))
+
+		if recovered != "boom" {
+			t.Errorf("expected to recover 'boom', got %v", recovered)
+		}
+	})
+}
+
+func TestDecodeMiddleware(t *testing.T) {
+	type move struct {
+		X int `json:"x"`
+	}
+	t.Run("should decode messages and reply with an error for invalid payloads", func(t *testing.T) {
+		room, handler, cleanup := setupTestRoom[string](t, "test-room-decode")
+		defer cleanup()
+		ss := newMockSocketSession[string]("player-1")
+		room.players["player-1"] = ss
+
+		var decoded []move
+		room.Use(
+			DecodeMiddleware[string, string, move](JSONCodec{}),
+			func(next Handler[string, string]) Handler[string, string] {
+				return func(msg *InboundMessage[string, string]) {
+					decoded = append(decoded, msg.Decoded.(move))
+					next(msg)
+				}
+			},
+		)
+
+		room.handleMessage(context.Background(), "player-1", []byte(`{"x":3}`))
+		room.handleMessage(context.Background(), "player-1", []byte(`not json`))
+
+		if len(decoded) != 1 || decoded[0].X != 3 {
+			t.Errorf("expected one decoded move with X=3, got %v", decoded)
+		}
+		if len(handler.GetOnMessageResults()) != 1 {
+			t.Errorf("expected invalid message to be dropped, got %d messages", len(handler.GetOnMessageResults()))
+		}
+		if len(ss.sentMessages) != 1 || !bytes.Contains(ss.sentMessages[0], []byte(`"type":"error"`)) {
+			t.Errorf("expected an error reply, got %q", ss.sentMessages)
+		}
+	})
+	t.Run("should pass the decoded message to OnMessageContext", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-decoded")
+		defer cleanup()
+		room.players["player-1"] = newMockSocketSession[string]("player-1")
+
+		var got move
+		var ok bool
+		room.onMessage = func(ctx context.Context, player string, message []byte) {
+			got, ok = DecodedMessage[move](ctx)
+		}
+		room.Use(DecodeMiddleware[string, string, move](JSONCodec{}))
+
+		room.handleMessage(context.Background(), "player-1", []byte(`{"x":7}`))
+		if !ok || got.X != 7 {
+			t.Errorf("expected the decoded move with X=7, got %v (%t)", got, ok)
+		}
+		if _, ok := DecodedMessage[string](context.Background()); ok {
+			t.Error("expected no decoded message in a plain context")
+		}
+	})
+}
+
+func TestLoggingAndMetricsMiddleware(t *testing.T) {
+	t.Run("should log and count messages", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-metrics", Options[string]{
+			OnMessage: func(player string, message []byte) {},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+
+		var buf strings.Builder
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+		metrics := &MessageMetrics{}
+		room.Use(
+			LoggingMiddleware[string, string](logger, slog.LevelInfo),
+			MetricsMiddleware[string, string](metrics),
+		)
+
+		room.handleMessage(context.Background(), "player-1", []byte("hello"))
+		room.handleMessage(context.Background(), "player-2", []byte("hi"))
+
+		snapshot := metrics.Snapshot()
+		if snapshot.Messages != 2 || snapshot.Bytes != 7 || snapshot.InFlight != 0 {
+			t.Errorf("unexpected metrics %+v", snapshot)
+		}
+		if !strings.Contains(buf.String(), "player=player-2") {
+			t.Errorf("expected log output to contain player-2, got %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var ErrInvalidOptions = errors.New("goroom: invalid options")
+
+// Options configures a Room. Every field is optional; the zero value gives an open room with a 30 second cleanup
+// period, no limits and the default slog logger.
+type Options[PlayerID comparable] struct {
+	// OnConnect is called after a player's connection has been admitted. Defaults to no-op.
+	OnConnect func(player PlayerID)
+	// OnDisconnect is called after a player's connection has closed. The player stays in the room until removed.
+	// Defaults to no-op.
+	OnDisconnect func(player PlayerID)
+	// OnRemove is called when a player is removed from the room by cleanup, locking or SetPlayers.
+	// Defaults to no-op.
+	OnRemove func(player PlayerID)
+	// OnMessage is called with each inbound message that passes ValidateMessage and the middleware chain.
+	// Defaults to discarding the message.
+	OnMessage func(player PlayerID, message []byte)
+
+	// The Context variants replace the callbacks above; only one of each pair may be set. Every context carries
+	// the values of the player's upgrade request and is cancelled when the room stops.
+	//
+	// OnConnectContext and OnMessageContext receive the player's session context, which is also cancelled when
+	// the player disconnects. The OnMessageContext context carries the message's trace span for use with the
+	// Context variants of the send methods, and any value decoded by DecodeMiddleware; see DecodedMessage.
+	OnConnectContext    func(ctx context.Context, player PlayerID)
+	OnDisconnectContext func(ctx context.Context, player PlayerID)
+	OnRemoveContext     func(ctx context.Context, player PlayerID)
+	OnMessageContext    func(ctx context.Context, player PlayerID, message []byte)
+
+	// IgnoreCleanup disables the periodic removal of disconnected players. It cannot be combined with
+	// CleanupPeriod.
+	IgnoreCleanup bool
+	// CleanupPeriod is how long a player may stay disconnected before removal. Defaults to 30 seconds.
+	CleanupPeriod time.Duration
+
+	// RateLimit applies per-player limits to inbound messages. The zero value disables limiting.
+	RateLimit RateLimit
+	// MaxMessageSize is the largest inbound message in bytes. Larger messages close the connection with 1009.
+	// Zero means there is no limit.
+	MaxMessageSize int64
+	// ValidateMessage runs on the raw message before the middleware chain and OnMessage. A non-nil error drops the
+	// message and replies to the player with ErrorReply. Defaults to accepting every message.
+	ValidateMessage func(player PlayerID, message []byte) error
+	// ErrorReply formats the reply sent for a rejected message. Defaults to DefaultErrorReply.
+	ErrorReply func(err error) []byte
+
+	// OnError is called with a *PanicError and the stack trace when a callback panics. Defaults to logging only.
+	OnError func(player PlayerID, err error, stack []byte)
+	// PanicPolicy decides what happens after a callback panics. Defaults to PanicLog.
+	PanicPolicy PanicPolicy
+
+	// Metrics receives counters from the room and its sessions. Defaults to NopMetrics.
+	Metrics Metrics
+
+	// Tracer creates spans for connects, messages, callbacks and sends. Defaults to NopTracer.
+	Tracer Tracer
+
+	// Recorder receives every inbound and outbound message and every player and status event, for example to write
+	// a recording that Replay can feed into another room. Defaults to no recording.
+	Recorder Recorder[PlayerID]
+
+	// DrainMessage is sent to every connected player when Drain starts, to warn them that the server is going
+	// away. Defaults to no message.
+	DrainMessage []byte
+	// DrainGracePeriod is how long Drain waits for players to leave before closing their connections with 1001
+	// (going away). Defaults to 5 seconds.
+	DrainGracePeriod time.Duration
+
+	// SnapshotStore saves a Snapshot of the room when it stops and every SnapshotInterval, for RestoreRoom to
+	// recreate it after a restart. Defaults to no snapshots.
+	SnapshotStore SnapshotStore
+	// SnapshotInterval is how often the room saves a snapshot while it runs. Zero only saves when the room stops.
+	// Periodic snapshots are saved alongside message processing, and one is skipped if the previous one is still
+	// being saved.
+	SnapshotInterval time.Duration
+	// SnapshotState returns the application's state to include in each snapshot. Defaults to none.
+	SnapshotState func() ([]byte, error)
+	// RestoreState is passed the application state from the snapshot a room is created from.
+	RestoreState func(state []byte) error
+
+	// Broker shares the room with rooms of the same ID on other nodes, so that players connected to different
+	// processes are in one room. Messages sent with SendMessageToPlayer and SendMessageToAllPlayers, presence and
+	// status changes reach every node; Broadcast and the room's callbacks remain local to each node. Defaults to a
+	// room on this node only.
+	Broker Broker
+	// NodeID identifies this node to the others sharing the room through the Broker. It must be unique among them.
+	// Defaults to a random ID.
+	NodeID string
+	// BrokerHeartbeat is how often this node sends the other nodes the list of its connected players. A node not
+	// heard from for three heartbeats is presumed gone, and its players disconnected. Defaults to 5 seconds.
+	BrokerHeartbeat time.Duration
+	// BrokerPublishTimeout bounds each publish to the Broker, and how long Stop waits for messages still queued for
+	// it. Defaults to 5 seconds.
+	BrokerPublishTimeout time.Duration
+
+	// Clock is the source of time for the room and its sessions. Defaults to RealClock.
+	Clock Clock
+
+	// Slogger is the parent logger for the room and its sessions. Defaults to slog.Default().
+	Slogger *slog.Logger
+	// LogLevel overrides the minimum level of Slogger for this room only. Defaults to Slogger's own level.
+	LogLevel slog.Leveler
+	// LogPayloads opts in to logging message payloads at debug level.
+	LogPayloads PayloadLogging
+}
+
+func (o Options[PlayerID]) validate() error {
+	if o.OnConnect != nil && o.OnConnectContext != nil {
+		return fmt.Errorf("%w: only one of OnConnect and OnConnectContext may be set", ErrInvalidOptions)
+	}
+	if o.OnDisconnect != nil && o.OnDisconnectContext != nil {
+		return fmt.Errorf("%w: only one of OnDisconnect and OnDisconnectContext may be set", ErrInvalidOptions)
+	}
+	if o.OnRemove != nil && o.OnRemoveContext != nil {
+		return fmt.Errorf("%w: only one of OnRemove and OnRemoveContext may be set", ErrInvalidOptions)
+	}
+	if o.OnMessage != nil && o.OnMessageContext != nil {
+		return fmt.Errorf("%w: only one of OnMessage and OnMessageContext may be set", ErrInvalidOptions)
+	}
+	if o.CleanupPeriod < 0 {
+		return fmt.Errorf("%w: CleanupPeriod must not be negative", ErrInvalidOptions)
+	}
+	if o.IgnoreCleanup && o.CleanupPeriod != 0 {
+		return fmt.Errorf("%w: CleanupPeriod cannot be set when IgnoreCleanup is true", ErrInvalidOptions)
+	}
+	if o.MaxMessageSize < 0 {
+		return fmt.Errorf("%w: MaxMessageSize must not be negative", ErrInvalidOptions)
+	}
+	rl := o.RateLimit
+	if rl.MessagesPerSecond < 0 || rl.BytesPerSecond < 0 || rl.MessageBurst < 0 || rl.BytesBurst < 0 {
+		return fmt.Errorf("%w: RateLimit values must not be negative", ErrInvalidOptions)
+	}
+	if rl.BytesPerSecond > 0 && rl.BytesBurst > 0 && int64(rl.BytesBurst) < o.MaxMessageSize {
+		return fmt.Errorf("%w: RateLimit.BytesBurst must be at least MaxMessageSize", ErrInvalidOptions)
+	}
+	if rl.Action < RateLimitDrop || rl.Action > RateLimitKick {
+		return fmt.Errorf("%w: unknown RateLimit.Action %d", ErrInvalidOptions, rl.Action)
+	}
+	if rl.Action == RateLimitWarn && rl.WarnMessage == nil {
+		return fmt.Errorf("%w: RateLimit.WarnMessage is required for RateLimitWarn", ErrInvalidOptions)
+	}
+	if o.DrainGracePeriod < 0 {
+		return fmt.Errorf("%w: DrainGracePeriod must not be negative", ErrInvalidOptions)
+	}
+	if o.SnapshotInterval < 0 {
+		return fmt.Errorf("%w: SnapshotInterval must not be negative", ErrInvalidOptions)
+	}
+	if o.SnapshotInterval > 0 && o.SnapshotStore == nil {
+		return fmt.Errorf("%w: SnapshotInterval requires a SnapshotStore", ErrInvalidOptions)
+	}
+	if o.NodeID != "" && o.Broker == nil {
+		return fmt.Errorf("%w: NodeID requires a Broker", ErrInvalidOptions)
+	}
+	if o.BrokerHeartbeat < 0 || o.BrokerPublishTimeout < 0 {
+		return fmt.Errorf("%w: BrokerHeartbeat and BrokerPublishTimeout must not be negative", ErrInvalidOptions)
+	}
+	if o.PanicPolicy < PanicLog || o.PanicPolicy > PanicStopRoom {
+		return fmt.Errorf("%w: unknown PanicPolicy %d", ErrInvalidOptions, o.PanicPolicy)
+	}
+	return nil
+}
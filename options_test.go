@@ -0,0 +1,151 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	httptest2 "github.com/getlantern/httptest"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options[string]
+		wantErr bool
+	}{
+		{name: "zero value", options: Options[string]{}},
+		{name: "ignore cleanup", options: Options[string]{IgnoreCleanup: true}},
+		{name: "negative cleanup period", options: Options[string]{CleanupPeriod: -time.Second}, wantErr: true},
+		{name: "ignore cleanup with cleanup period", options: Options[string]{IgnoreCleanup: true, CleanupPeriod: time.Second}, wantErr: true},
+		{name: "negative max message size", options: Options[string]{MaxMessageSize: -1}, wantErr: true},
+		{name: "negative rate", options: Options[string]{RateLimit: RateLimit{MessagesPerSecond: -1}}, wantErr: true},
+		{name: "byte burst below max message size", options: Options[string]{MaxMessageSize: 1024, RateLimit: RateLimit{BytesPerSecond: 100, BytesBurst: 512}}, wantErr: true},
+		{name: "byte burst of max message size", options: Options[string]{MaxMessageSize: 1024, RateLimit: RateLimit{BytesPerSecond: 100, BytesBurst: 1024}}},
+		{name: "unknown rate limit action", options: Options[string]{RateLimit: RateLimit{Action: RateLimitAction(9)}}, wantErr: true},
+		{name: "warn without message", options: Options[string]{RateLimit: RateLimit{MessagesPerSecond: 1, Action: RateLimitWarn}}, wantErr: true},
+		{name: "warn with message", options: Options[string]{RateLimit: RateLimit{MessagesPerSecond: 1, Action: RateLimitWarn, WarnMessage: []byte("slow")}}},
+		{name: "snapshot interval without store", options: Options[string]{SnapshotInterval: time.Second}, wantErr: true},
+		{name: "negative snapshot interval", options: Options[string]{SnapshotStore: &FileSnapshotStore{}, SnapshotInterval: -1}, wantErr: true},
+		{name: "unknown panic policy", options: Options[string]{PanicPolicy: PanicPolicy(-1)}, wantErr: true},
+		{name: "node id without broker", options: Options[string]{NodeID: "a"}, wantErr: true},
+		{name: "negative broker heartbeat", options: Options[string]{Broker: NewMemoryBroker(), BrokerHeartbeat: -1}, wantErr: true},
+		{name: "broker with node id", options: Options[string]{Broker: NewMemoryBroker(), NodeID: "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room, err := NewRoom[string, string](context.Background(), "test-room-options", tt.options)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidOptions) {
+					t.Fatalf("expected ErrInvalidOptions, got %v", err)
+				}
+				if room != nil {
+					t.Fatal("expected no room to be returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+// syncBuffer is a strings.Builder that is safe to write to from the room's goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRoom_WithoutCallbacks(t *testing.T) {
+	setup := func(t *testing.T) (*Room[string, string], *syncBuffer) {
+		var buf syncBuffer
+		room, err := NewRoom[string, string](context.Background(), "test-room-no-callbacks", Options[string]{
+			Slogger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		return room, &buf
+	}
+	assertNoPanics := func(t *testing.T, buf *syncBuffer) {
+		t.Helper()
+		// Callbacks run on their own goroutines, so give them a moment to fail.
+		time.Sleep(20 * time.Millisecond)
+		if strings.Contains(buf.String(), "callback panicked") {
+			t.Fatalf("expected no callback panics, got %s", buf.String())
+		}
+	}
+
+	t.Run("should handle messages and disconnects", func(t *testing.T) {
+		room, buf := setup(t)
+		go room.Start()
+		defer room.Stop()
+
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Message, Message: []byte("hi")}
+		room.messages <- SocketMessage[string]{ReferenceID: "player-1", Type: Disconnect}
+
+		assertNoPanics(t, buf)
+	})
+	t.Run("should remove players", func(t *testing.T) {
+		room, buf := setup(t)
+		room.players["player-1"] = nil
+		room.lastSeen["player-1"] = time.Now().Add(-time.Hour)
+		room.CleanUpPlayers()
+
+		room.players["player-2"] = nil
+		room.SetStatus(Locked)
+
+		room.players["player-3"] = newMockSocketSession[string]("player-3")
+		_ = room.SetPlayers(nil)
+
+		if len(room.players) != 0 {
+			t.Errorf("expected all players to be removed, got %d", len(room.players))
+		}
+		assertNoPanics(t, buf)
+	})
+	t.Run("should connect players", func(t *testing.T) {
+		room, buf := setup(t)
+		go room.Start()
+		defer room.Stop()
+
+		key, err := generateChallengeKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		testW := httptest2.NewRecorder(nil)
+		testR := httptest.NewRequest("GET", "/", nil)
+		testR.Header.Set("Upgrade", "websocket")
+		testR.Header.Set("Connection", "Upgrade")
+		testR.Header.Set("Sec-WebSocket-Version", "13")
+		testR.Header.Set("Sec-WebSocket-Key", key)
+
+		room.HandleSocketWithPlayer("player-1", func(w http.ResponseWriter, r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})(testW, testR)
+
+		// The recorder closes the connection straight away, so the player is known but may already be disconnected.
+		if presences := room.GetPlayerPresences(); len(presences) != 1 || presences[0].ID != "player-1" {
+			t.Errorf("expected player-1 to have joined, got %v", presences)
+		}
+		assertNoPanics(t, buf)
+	})
+}
@@ -0,0 +1,58 @@
+package otelgoroom
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chilledoj/goroom"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	t.Run("should create parented spans with converted attributes", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		var tracer goroom.Tracer = NewTracer(provider.Tracer("test"))
+
+		ctx, parent := tracer.Start(context.Background(), "goroom.message",
+			goroom.Attr("goroom.player", 7),
+			goroom.Attr("goroom.room", "lobby"),
+		)
+		_, child := tracer.Start(ctx, "goroom.OnMessage")
+		child.RecordError(errors.New("boom"))
+		child.End()
+		parent.SetAttributes(goroom.Attr("goroom.suppressed", true))
+		parent.End()
+
+		spans := recorder.Ended()
+		if len(spans) != 2 {
+			t.Fatalf("expected 2 spans, got %d", len(spans))
+		}
+		childSpan, parentSpan := spans[0], spans[1]
+		if childSpan.Parent().SpanID() != parentSpan.SpanContext().SpanID() {
+			t.Error("expected OnMessage span to be a child of the message span")
+		}
+		if childSpan.Status().Code != codes.Error {
+			t.Errorf("expected error status, got %v", childSpan.Status())
+		}
+
+		want := map[attribute.Key]attribute.Value{
+			"goroom.player":     attribute.IntValue(7),
+			"goroom.room":       attribute.StringValue("lobby"),
+			"goroom.suppressed": attribute.BoolValue(true),
+		}
+		for _, kv := range parentSpan.Attributes() {
+			if w, ok := want[kv.Key]; ok && w != kv.Value {
+				t.Errorf("expected %s to be %v, got %v", kv.Key, w.Emit(), kv.Value.Emit())
+			}
+			delete(want, kv.Key)
+		}
+		if len(want) != 0 {
+			t.Errorf("missing attributes %v", want)
+		}
+	})
+}
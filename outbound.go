@@ -0,0 +1,72 @@
+package goroom
+
+import "context"
+
+// OutboundInterceptor runs once for each recipient of an outgoing message. It returns the payload that recipient
+// should receive, which may be the original message, a rewritten copy, or nil to suppress delivery.
+type OutboundInterceptor[PlayerID comparable] func(recipient PlayerID, message []byte) []byte
+
+// Intercept appends interceptors to the room's outbound chain. They are applied in the order they were added to
+// every message sent with SendMessageToPlayer, SendMessageToAllPlayers and Broadcast.
+func (room *Room[RoomId, PlayerID]) Intercept(interceptors ...OutboundInterceptor[PlayerID]) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+	room.interceptors = append(room.interceptors, interceptors...)
+}
+
+// Broadcast sends each connected player the payload returned by build for that player. A nil payload skips the
+// player. build is called without the room lock held, so it may safely call back into the room.
+func (room *Room[RoomId, PlayerID]) Broadcast(build func(player PlayerID) []byte) {
+	room.BroadcastContext(room.ctx, build)
+}
+
+// BroadcastContext is Broadcast with a context for tracing, usually the one passed to OnMessageContext.
+func (room *Room[RoomId, PlayerID]) BroadcastContext(ctx context.Context, build func(player PlayerID) []byte) {
+	sessions, interceptors := room.connectedSessions()
+	ctx, span := room.tracer.Start(ctx, "goroom.broadcast",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.recipients", len(sessions)),
+	)
+	defer span.End()
+	for _, ss := range sessions {
+		player := ss.ReferenceID()
+		message := build(player)
+		if message == nil {
+			continue
+		}
+		room.deliver(ctx, ss, player, message, interceptors)
+	}
+}
+
+// connectedSessions snapshots the connected sessions and interceptors so that sending happens outside the lock.
+func (room *Room[RoomId, PlayerID]) connectedSessions() ([]SocketSessioner[PlayerID], []OutboundInterceptor[PlayerID]) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+	sessions := make([]SocketSessioner[PlayerID], 0, len(room.players))
+	for _, p := range room.players {
+		if p == nil {
+			continue
+		}
+		sessions = append(sessions, p)
+	}
+	return sessions, room.interceptors
+}
+
+func (room *Room[RoomId, PlayerID]) deliver(ctx context.Context, ss SocketSessioner[PlayerID], player PlayerID, message []byte, interceptors []OutboundInterceptor[PlayerID]) {
+	_, span := room.tracer.Start(ctx, "goroom.send",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.player", player),
+	)
+	defer span.End()
+	for _, intercept := range interceptors {
+		message = intercept(player, message)
+		if message == nil {
+			room.Slogger.Debug("message suppressed", "func", "room.deliver", "player", player)
+			span.SetAttributes(Attr("goroom.suppressed", true))
+			return
+		}
+	}
+	span.SetAttributes(Attr("goroom.message.size", len(message)))
+	room.record(RecordOutbound, player, 0, message)
+	ss.Send(message)
+}
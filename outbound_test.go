@@ -0,0 +1,94 @@
+package goroom
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRoom_Intercept(t *testing.T) {
+	t.Run("should rewrite and suppress broadcasts per recipient", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-intercept")
+		defer cleanup()
+
+		players := []string{"player-1", "player-2", "player-3"}
+		sessions := make(map[string]*mockSocketSession[string])
+		for _, p := range players {
+			sessions[p] = newMockSocketSession[string](p)
+			room.players[p] = sessions[p]
+		}
+
+		room.Intercept(
+			func(recipient string, message []byte) []byte {
+				if recipient == "player-3" {
+					return nil
+				}
+				return message
+			},
+			func(recipient string, message []byte) []byte {
+				if recipient == "player-2" {
+					return bytes.ReplaceAll(message, []byte("secret"), []byte("******"))
+				}
+				return message
+			},
+		)
+
+		room.SendMessageToAllPlayers([]byte("the secret card"))
+
+		if got := sessions["player-1"].sentMessages; len(got) != 1 || string(got[0]) != "the secret card" {
+			t.Errorf("expected player-1 to receive the original message, got %q", got)
+		}
+		if got := sessions["player-2"].sentMessages; len(got) != 1 || string(got[0]) != "the ****** card" {
+			t.Errorf("expected player-2 to receive the redacted message, got %q", got)
+		}
+		if got := sessions["player-3"].sentMessages; len(got) != 0 {
+			t.Errorf("expected player-3 to receive nothing, got %q", got)
+		}
+	})
+	t.Run("should apply to messages sent to a single player", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-intercept")
+		defer cleanup()
+		ss := newMockSocketSession[string]("player-1")
+		room.players["player-1"] = ss
+
+		room.Intercept(func(recipient string, message []byte) []byte {
+			return append([]byte(recipient+":"), message...)
+		})
+		room.SendMessageToPlayer("player-1", []byte("hello"))
+
+		if len(ss.sentMessages) != 1 || string(ss.sentMessages[0]) != "player-1:hello" {
+			t.Errorf("expected intercepted message, got %q", ss.sentMessages)
+		}
+	})
+}
+
+func TestRoom_Broadcast(t *testing.T) {
+	t.Run("should build a payload for each connected player", func(t *testing.T) {
+		room, _, cleanup := setupTestRoom[string](t, "test-room-broadcast")
+		defer cleanup()
+
+		hands := map[string]string{"player-1": "ace", "player-2": "king"}
+		sessions := make(map[string]*mockSocketSession[string])
+		for p := range hands {
+			sessions[p] = newMockSocketSession[string](p)
+			room.players[p] = sessions[p]
+		}
+		room.players["player-3"] = nil
+
+		built := 0
+		room.Broadcast(func(player string) []byte {
+			built++
+			// Building a payload may call back into the room without deadlocking.
+			_ = room.GetPlayerPresence(player)
+			return []byte(hands[player])
+		})
+
+		if built != 2 {
+			t.Errorf("expected payloads to be built for 2 connected players, got %d", built)
+		}
+		for p, hand := range hands {
+			if got := sessions[p].sentMessages; len(got) != 1 || string(got[0]) != hand {
+				t.Errorf("expected %s to receive %s, got %q", p, hand, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package goroom
+
+import "context"
+
+// playerContext holds the values of a player's most recent upgrade request and cancels their session context.
+type playerContext struct {
+	values context.Context
+	cancel context.CancelFunc
+}
+
+// mergedContext is cancelled with the room, but looks values up in the player's request first.
+type mergedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c mergedContext) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// newPlayerContext replaces the player's context with one carrying the values of ctx. The returned context is
+// cancelled when the player disconnects or the room stops. The caller must hold room.mu.
+func (room *Room[RoomId, PlayerID]) newPlayerContext(ctx context.Context, player PlayerID) context.Context {
+	if pc, ok := room.contexts[player]; ok {
+		pc.cancel()
+	}
+	// WithoutCancel stops the request's cancellation, which happens as soon as the handler returns, leaking in.
+	values := context.WithoutCancel(ctx)
+	pctx, cancel := context.WithCancel(mergedContext{Context: room.ctx, values: values})
+	room.contexts[player] = playerContext{values: values, cancel: cancel}
+	return pctx
+}
+
+// playerContext returns a context carrying the player's request values that is cancelled when the room stops.
+// The caller must hold room.mu.
+func (room *Room[RoomId, PlayerID]) playerContext(player PlayerID) context.Context {
+	pc, ok := room.contexts[player]
+	if !ok {
+		return room.ctx
+	}
+	return mergedContext{Context: room.ctx, values: pc.values}
+}
+
+func withContext[PlayerID comparable](fn func(player PlayerID), ctxFn func(ctx context.Context, player PlayerID)) func(ctx context.Context, player PlayerID) {
+	if ctxFn != nil {
+		return ctxFn
+	}
+	if fn == nil {
+		return nil
+	}
+	return func(_ context.Context, player PlayerID) {
+		fn(player)
+	}
+}
@@ -0,0 +1,98 @@
+package goroom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	httptest2 "github.com/getlantern/httptest"
+)
+
+type requestKey struct{}
+
+func newUpgradeRequest(t *testing.T, ctx context.Context) *http.Request {
+	t.Helper()
+	key, err := generateChallengeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequestWithContext(ctx, "GET", "/", nil)
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", key)
+	return r
+}
+
+func TestRoom_ContextCallbacks(t *testing.T) {
+	t.Run("should pass request values and cancel contexts on disconnect and room stop", func(t *testing.T) {
+		connected := make(chan context.Context, 1)
+		disconnected := make(chan context.Context, 1)
+		removed := make(chan context.Context, 1)
+		room, err := NewRoom[string, string](context.Background(), "test-room-context", Options[string]{
+			OnConnectContext:    func(ctx context.Context, player string) { connected <- ctx },
+			OnDisconnectContext: func(ctx context.Context, player string) { disconnected <- ctx },
+			OnRemoveContext:     func(ctx context.Context, player string) { removed <- ctx },
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+
+		reqCtx, cancelReq := context.WithCancel(context.WithValue(context.Background(), requestKey{}, "request-value"))
+		room.HandleSocketWithPlayer("player-1", func(w http.ResponseWriter, r *http.Request, err error) {
+			t.Errorf("unexpected error: %v", err)
+		})(httptest2.NewRecorder(nil), newUpgradeRequest(t, reqCtx))
+		// The request finishing must not cancel the player's contexts.
+		cancelReq()
+
+		wait := func(ch chan context.Context, name string) context.Context {
+			t.Helper()
+			select {
+			case ctx := <-ch:
+				if ctx.Value(requestKey{}) != "request-value" {
+					t.Errorf("expected %s context to carry the request value", name)
+				}
+				return ctx
+			case <-time.After(time.Second):
+				t.Fatalf("timed out waiting for %s", name)
+			}
+			return nil
+		}
+
+		connCtx := wait(connected, "OnConnectContext")
+		// The recorder closes the connection straight away, so the player disconnects.
+		discCtx := wait(disconnected, "OnDisconnectContext")
+		select {
+		case <-connCtx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the connect context to be cancelled when the player disconnects")
+		}
+		if discCtx.Err() != nil {
+			t.Fatal("expected the disconnect context to live until the room stops")
+		}
+
+		room.SetStatus(Locked)
+		remCtx := wait(removed, "OnRemoveContext")
+
+		room.Stop()
+		for name, ctx := range map[string]context.Context{"disconnect": discCtx, "remove": remCtx} {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+				t.Fatalf("expected the %s context to be cancelled when the room stops", name)
+			}
+		}
+	})
+	t.Run("should reject setting both forms of a callback", func(t *testing.T) {
+		_, err := NewRoom[string, string](context.Background(), "test-room-context", Options[string]{
+			OnRemove:        func(player string) {},
+			OnRemoveContext: func(ctx context.Context, player string) {},
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package goroom
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type RateLimitAction int
+
+const (
+	// RateLimitDrop silently discards messages over the limit.
+	RateLimitDrop RateLimitAction = iota
+	// RateLimitWarn discards messages over the limit and sends RateLimit.WarnMessage to the player.
+	RateLimitWarn
+	// RateLimitKick closes the player's connection with a policy violation (1008).
+	RateLimitKick
+)
+
+func (a RateLimitAction) String() string {
+	switch a {
+	case RateLimitDrop:
+		return "Drop"
+	case RateLimitWarn:
+		return "Warn"
+	case RateLimitKick:
+		return "Kick"
+	default:
+		return "Unknown"
+	}
+}
+
+// RateLimit configures per-player token buckets for inbound traffic. A zero rate disables that bucket.
+// A zero burst defaults to one second's worth of the rate, but at least one message and, for bytes, at least
+// Options.MaxMessageSize, so that every message that may be sent can pass. Without a MaxMessageSize, set
+// BytesBurst to at least the largest message.
+type RateLimit struct {
+	MessagesPerSecond float64
+	MessageBurst      int
+	BytesPerSecond    float64
+	BytesBurst        int
+
+	Action      RateLimitAction
+	WarnMessage []byte
+}
+
+func (rl RateLimit) enabled() bool {
+	return rl.MessagesPerSecond > 0 || rl.BytesPerSecond > 0
+}
+
+// withMaxMessageSize raises a defaulted byte burst to the largest allowed message.
+func (rl RateLimit) withMaxMessageSize(size int64) RateLimit {
+	if rl.BytesPerSecond > 0 && rl.BytesBurst == 0 && float64(size) > rl.BytesPerSecond {
+		rl.BytesBurst = int(size)
+	}
+	return rl
+}
+
+// ThrottleStats are the room-level counters of inbound traffic rejected by the rate limiter.
+type ThrottleStats struct {
+	Messages uint64
+	Bytes    uint64
+	Warnings uint64
+	Kicks    uint64
+}
+
+type throttleCounters struct {
+	messages atomic.Uint64
+	bytes    atomic.Uint64
+	warnings atomic.Uint64
+	kicks    atomic.Uint64
+}
+
+func (tc *throttleCounters) record(action RateLimitAction, size int) {
+	tc.messages.Add(1)
+	tc.bytes.Add(uint64(size))
+	switch action {
+	case RateLimitWarn:
+		tc.warnings.Add(1)
+	case RateLimitKick:
+		tc.kicks.Add(1)
+	}
+}
+
+func (tc *throttleCounters) stats() ThrottleStats {
+	return ThrottleStats{
+		Messages: tc.messages.Load(),
+		Bytes:    tc.bytes.Load(),
+		Warnings: tc.warnings.Load(),
+		Kicks:    tc.kicks.Load(),
+	}
+}
+
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	b := float64(burst)
+	if b <= 0 {
+		// A burst below one could never hold a whole message or byte.
+		b = max(rate, 1)
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  b,
+		tokens: b,
+		last:   now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+}
+
+// rateLimiter is owned by a single session's read loop and is therefore not safe for concurrent use.
+type rateLimiter struct {
+	messages *tokenBucket
+	bytes    *tokenBucket
+}
+
+func newRateLimiter(rl RateLimit, now time.Time) *rateLimiter {
+	if !rl.enabled() {
+		return nil
+	}
+	limiter := &rateLimiter{}
+	if rl.MessagesPerSecond > 0 {
+		limiter.messages = newTokenBucket(rl.MessagesPerSecond, rl.MessageBurst, now)
+	}
+	if rl.BytesPerSecond > 0 {
+		limiter.bytes = newTokenBucket(rl.BytesPerSecond, rl.BytesBurst, now)
+	}
+	return limiter
+}
+
+// allow reports whether a message of the given size fits in both buckets, consuming tokens only if it does.
+func (l *rateLimiter) allow(size int, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	if l.messages != nil {
+		l.messages.refill(now)
+		if l.messages.tokens < 1 {
+			return false
+		}
+	}
+	if l.bytes != nil {
+		l.bytes.refill(now)
+		if l.bytes.tokens < float64(size) {
+			return false
+		}
+		l.bytes.tokens -= float64(size)
+	}
+	if l.messages != nil {
+		l.messages.tokens--
+	}
+	return true
+}
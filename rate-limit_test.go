@@ -0,0 +1,192 @@
+package goroom
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	t.Run("should allow messages up to the burst and then refill over time", func(t *testing.T) {
+		now := time.Now()
+		limiter := newRateLimiter(RateLimit{MessagesPerSecond: 2, MessageBurst: 3}, now)
+
+		for i := 0; i < 3; i++ {
+			if !limiter.allow(1, now) {
+				t.Fatalf("expected message %d to be allowed", i)
+			}
+		}
+		if limiter.allow(1, now) {
+			t.Fatal("expected message over the burst to be rejected")
+		}
+		if !limiter.allow(1, now.Add(500*time.Millisecond)) {
+			t.Fatal("expected a token to be refilled after 500ms")
+		}
+		if limiter.allow(1, now.Add(500*time.Millisecond)) {
+			t.Fatal("expected only one token to be refilled")
+		}
+	})
+	t.Run("should limit on bytes without consuming message tokens", func(t *testing.T) {
+		now := time.Now()
+		limiter := newRateLimiter(RateLimit{MessagesPerSecond: 10, BytesPerSecond: 100}, now)
+
+		if !limiter.allow(80, now) {
+			t.Fatal("expected 80 bytes to be allowed")
+		}
+		if limiter.allow(80, now) {
+			t.Fatal("expected a further 80 bytes to be rejected")
+		}
+		if limiter.messages.tokens != 9 {
+			t.Errorf("expected rejected message to not consume a message token, got %v tokens", limiter.messages.tokens)
+		}
+		if !limiter.allow(20, now) {
+			t.Fatal("expected the remaining 20 bytes to be allowed")
+		}
+	})
+	t.Run("should allow a whole message with a fractional rate and no burst", func(t *testing.T) {
+		now := time.Now()
+		limiter := newRateLimiter(RateLimit{MessagesPerSecond: 0.5}, now)
+
+		if !limiter.allow(1, now) {
+			t.Fatal("expected the first message to be allowed")
+		}
+		if limiter.allow(1, now.Add(time.Second)) {
+			t.Fatal("expected a second message within two seconds to be rejected")
+		}
+		if !limiter.allow(1, now.Add(2*time.Second)) {
+			t.Fatal("expected a message to be allowed after two seconds")
+		}
+	})
+	t.Run("should default the byte burst to the max message size", func(t *testing.T) {
+		now := time.Now()
+		rl := RateLimit{BytesPerSecond: 100}.withMaxMessageSize(1024)
+		limiter := newRateLimiter(rl, now)
+
+		if !limiter.allow(1024, now) {
+			t.Fatal("expected a message of the max size to be allowed")
+		}
+		if limiter.allow(1, now) {
+			t.Fatal("expected the bucket to be empty")
+		}
+		if got := (RateLimit{BytesPerSecond: 100, BytesBurst: 2048}).withMaxMessageSize(1024).BytesBurst; got != 2048 {
+			t.Errorf("expected an explicit burst to be kept, got %d", got)
+		}
+	})
+	t.Run("should allow everything when disabled", func(t *testing.T) {
+		limiter := newRateLimiter(RateLimit{}, time.Now())
+		if limiter != nil {
+			t.Fatal("expected no limiter for a zero RateLimit")
+		}
+		if !limiter.allow(1<<20, time.Now()) {
+			t.Fatal("expected nil limiter to allow")
+		}
+	})
+}
+
+func setupLimitedSession(t *testing.T, rl RateLimit) (*SocketSession[string], net.Conn, chan SocketMessage[string], *throttleCounters) {
+	serverConn, clientConn := net.Pipe()
+	messages := make(chan SocketMessage[string], 10)
+	counters := &throttleCounters{}
+	session := newSocketSession(serverConn, "player1", messages, sessionConfig{
+		rateLimit:  rl,
+		onThrottle: counters.record,
+	})
+	t.Cleanup(func() {
+		session.Close()
+		_ = clientConn.Close()
+	})
+	return session, clientConn, messages, counters
+}
+
+func TestSocketSession_RateLimit(t *testing.T) {
+	t.Run("should drop messages over the limit", func(t *testing.T) {
+		_, clientConn, messages, counters := setupLimitedSession(t, RateLimit{MessagesPerSecond: 1, MessageBurst: 1})
+
+		for _, m := range []string{"first", "second"} {
+			if err := wsutil.WriteClientBinary(clientConn, []byte(m)); err != nil {
+				t.Fatalf("Failed to write client message: %v", err)
+			}
+		}
+
+		select {
+		case msg := <-messages:
+			if string(msg.Message) != "first" {
+				t.Errorf("expected first message to be forwarded, got '%s'", msg.Message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message from ReadLoop")
+		}
+		select {
+		case msg := <-messages:
+			t.Fatalf("expected second message to be dropped, got '%s'", msg.Message)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if stats := counters.stats(); stats.Messages != 1 || stats.Bytes != uint64(len("second")) {
+			t.Errorf("expected 1 throttled message of %d bytes, got %+v", len("second"), stats)
+		}
+	})
+	t.Run("should send the warn message to the player", func(t *testing.T) {
+		warning := []byte("slow down")
+		_, clientConn, _, counters := setupLimitedSession(t, RateLimit{
+			MessagesPerSecond: 1,
+			MessageBurst:      1,
+			Action:            RateLimitWarn,
+			WarnMessage:       warning,
+		})
+
+		go func() {
+			_ = wsutil.WriteClientBinary(clientConn, []byte("first"))
+			_ = wsutil.WriteClientBinary(clientConn, []byte("second"))
+		}()
+
+		msg, _, err := wsutil.ReadServerData(clientConn)
+		if err != nil {
+			t.Fatalf("Failed to read server data from pipe: %v", err)
+		}
+		if !bytes.Equal(msg, warning) {
+			t.Errorf("expected warning '%s', got '%s'", warning, msg)
+		}
+		if stats := counters.stats(); stats.Warnings != 1 {
+			t.Errorf("expected 1 warning, got %+v", stats)
+		}
+	})
+	t.Run("should kick the player with a policy violation", func(t *testing.T) {
+		_, clientConn, messages, counters := setupLimitedSession(t, RateLimit{
+			BytesPerSecond: 4,
+			Action:         RateLimitKick,
+		})
+
+		go func() {
+			_ = wsutil.WriteClientBinary(clientConn, []byte("too many bytes"))
+		}()
+
+		frame, err := ws.ReadFrame(clientConn)
+		if err != nil {
+			t.Fatalf("Failed to read close frame: %v", err)
+		}
+		if frame.Header.OpCode != ws.OpClose {
+			t.Fatalf("expected close frame, got %v", frame.Header.OpCode)
+		}
+		code, _ := ws.ParseCloseFrameData(frame.Payload)
+		if code != ws.StatusPolicyViolation {
+			t.Errorf("expected close code %d, got %d", ws.StatusPolicyViolation, code)
+		}
+
+		select {
+		case msg := <-messages:
+			if msg.Type != Disconnect {
+				t.Errorf("expected disconnect message, got %v", msg.Type)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for disconnect message")
+		}
+		if stats := counters.stats(); stats.Kicks != 1 {
+			t.Errorf("expected 1 kick, got %+v", stats)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package goroom
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type RecordKind int
+
+const (
+	RecordInbound RecordKind = iota
+	RecordOutbound
+	RecordConnect
+	RecordDisconnect
+	RecordRemove
+	RecordStatus
+	RecordStopped
+)
+
+var recordKindNames = map[RecordKind]string{
+	RecordInbound:    "inbound",
+	RecordOutbound:   "outbound",
+	RecordConnect:    "connect",
+	RecordDisconnect: "disconnect",
+	RecordRemove:     "remove",
+	RecordStatus:     "status",
+	RecordStopped:    "stopped",
+}
+
+func (k RecordKind) String() string {
+	if name, ok := recordKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (k RecordKind) MarshalText() ([]byte, error) {
+	if _, ok := recordKindNames[k]; !ok {
+		return nil, fmt.Errorf("unknown record kind %d", int(k))
+	}
+	return []byte(k.String()), nil
+}
+
+func (k *RecordKind) UnmarshalText(text []byte) error {
+	for kind, name := range recordKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown record kind %q", text)
+}
+
+// Record is one entry in a room recording. Player is the sender of an inbound message, the recipient of an
+// outbound one and the subject of a player event. Status is only set for RecordStatus and Payload only for messages.
+type Record[PlayerID comparable] struct {
+	Time    time.Time
+	Kind    RecordKind
+	Player  PlayerID
+	Status  RoomStatus
+	Payload []byte
+}
+
+type recordJSON[PlayerID comparable] struct {
+	Time    time.Time  `json:"time"`
+	Kind    RecordKind `json:"kind"`
+	Player  *PlayerID  `json:"player,omitempty"`
+	Status  string     `json:"status,omitempty"`
+	Payload []byte     `json:"payload,omitempty"`
+}
+
+func (r Record[PlayerID]) MarshalJSON() ([]byte, error) {
+	v := recordJSON[PlayerID]{Time: r.Time, Kind: r.Kind, Payload: r.Payload}
+	switch r.Kind {
+	case RecordStatus:
+		v.Status = r.Status.String()
+	case RecordStopped:
+	default:
+		v.Player = &r.Player
+	}
+	return json.Marshal(v)
+}
+
+func (r *Record[PlayerID]) UnmarshalJSON(data []byte) error {
+	var v recordJSON[PlayerID]
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = Record[PlayerID]{Time: v.Time, Kind: v.Kind, Payload: v.Payload}
+	if v.Player != nil {
+		r.Player = *v.Player
+	}
+	if v.Kind == RecordStatus {
+		status, err := ParseRoomStatus(v.Status)
+		if err != nil {
+			return err
+		}
+		r.Status = status
+	}
+	return nil
+}
+
+// Recorder receives every message and event in a room, as set by Options.Recorder. Record is called synchronously
+// from the room and its callbacks, so it must be safe for concurrent use and should not block.
+type Recorder[PlayerID comparable] interface {
+	Record(rec Record[PlayerID])
+}
+
+// JSONRecorder writes a recording as one JSON encoded Record per line.
+type JSONRecorder[PlayerID comparable] struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	closer io.Closer
+	err    error
+}
+
+// NewJSONRecorder returns a recorder writing to w.
+func NewJSONRecorder[PlayerID comparable](w io.Writer) *JSONRecorder[PlayerID] {
+	return &JSONRecorder[PlayerID]{enc: json.NewEncoder(w)}
+}
+
+// NewFileRecorder returns a recorder appending to the file at path, creating it if necessary. Close closes the file.
+func NewFileRecorder[PlayerID comparable](path string) (*JSONRecorder[PlayerID], error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r := NewJSONRecorder[PlayerID](f)
+	r.closer = f
+	return r, nil
+}
+
+// Record writes the record. After a write fails further records are discarded and Err reports the failure.
+func (r *JSONRecorder[PlayerID]) Record(rec Record[PlayerID]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(rec)
+}
+
+// Err returns the first error writing the recording.
+func (r *JSONRecorder[PlayerID]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close closes the underlying file, if the recorder was made with NewFileRecorder, and returns the first error
+// writing the recording.
+func (r *JSONRecorder[PlayerID]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+	return r.err
+}
+
+// ReadRecording reads a recording written by a JSONRecorder.
+func ReadRecording[PlayerID comparable](r io.Reader) ([]Record[PlayerID], error) {
+	var records []Record[PlayerID]
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Record[PlayerID]
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return records, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, sc.Err()
+}
+
+// ReadRecordingFile reads a recording from the file at path.
+func ReadRecordingFile[PlayerID comparable](path string) ([]Record[PlayerID], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadRecording[PlayerID](f)
+}
+
+// record passes a record to the room's recorder, if it has one.
+func (room *Room[RoomId, PlayerID]) record(kind RecordKind, player PlayerID, status RoomStatus, payload []byte) {
+	if room.opts.Recorder == nil {
+		return
+	}
+	room.opts.Recorder.Record(Record[PlayerID]{
+		Time:    room.clock.Now(),
+		Kind:    kind,
+		Player:  player,
+		Status:  status,
+		Payload: payload,
+	})
+}
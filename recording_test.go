@@ -0,0 +1,206 @@
+package goroom
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoRoom starts a room that broadcasts every message it receives, recording to rec.
+func echoRoom(t *testing.T, rec Recorder[string]) *Room[string, string] {
+	t.Helper()
+	var room *Room[string, string]
+	room, err := NewRoom[string, string](context.Background(), "recorded", Options[string]{
+		OnMessage: func(player string, message []byte) {
+			room.SendMessageToAllPlayers(message)
+		},
+		Recorder:      rec,
+		IgnoreCleanup: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	t.Cleanup(room.Stop)
+	return room
+}
+
+func expectReceived(t *testing.T, c *MemoryClient[string], want string) {
+	t.Helper()
+	select {
+	case msg := <-c.Receive():
+		if string(msg) != want {
+			t.Fatalf("expected '%s', got '%s'", want, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for '%s'", want)
+	}
+}
+
+// sliceRecorder keeps records in memory.
+type sliceRecorder struct {
+	mu      sync.Mutex
+	records []Record[string]
+}
+
+func (r *sliceRecorder) Record(rec Record[string]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = append(r.records, rec)
+}
+
+// summary lists the records of the given kinds as "kind player payload", sorted so that the order of concurrent
+// callbacks does not matter.
+func (r *sliceRecorder) summary(kinds ...RecordKind) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for _, rec := range r.records {
+		if slices.Contains(kinds, rec.Kind) {
+			out = append(out, rec.Kind.String()+" "+rec.Player+" "+string(rec.Payload))
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var buf bytes.Buffer
+	recorder := NewJSONRecorder[string](&buf)
+	original := &sliceRecorder{}
+	room := echoRoom(t, multiRecorder{recorder, original})
+
+	alice, err := room.ConnectMemory(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := room.ConnectMemory(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.Send([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	expectReceived(t, alice, "hello")
+	expectReceived(t, bob, "hello")
+	room.SetStatus(Locked)
+	bob.Close()
+	if err := alice.Send([]byte{0xff, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+	expectReceived(t, alice, string([]byte{0xff, 0x00}))
+
+	records, err := ReadRecording[string](&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording returned error: %v", err)
+	}
+	if recorder.Err() != nil {
+		t.Fatalf("recorder error: %v", recorder.Err())
+	}
+	kinds := make([]RecordKind, 0, len(records))
+	for _, rec := range records {
+		kinds = append(kinds, rec.Kind)
+	}
+	for _, want := range []RecordKind{RecordConnect, RecordInbound, RecordOutbound, RecordStatus, RecordDisconnect} {
+		if !slices.Contains(kinds, want) {
+			t.Errorf("expected a %s record, got %v", want, kinds)
+		}
+	}
+
+	replayed := &sliceRecorder{}
+	fresh := echoRoom(t, replayed)
+	if err := Replay(context.Background(), fresh, records, ReplayOptions{}); err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if fresh.Status != Locked {
+		t.Errorf("expected the replayed room to be locked, got %v", fresh.Status)
+	}
+
+	kindsToCompare := []RecordKind{RecordConnect, RecordInbound, RecordOutbound, RecordDisconnect}
+	want := original.summary(kindsToCompare...)
+	deadline := time.After(time.Second)
+	for !slices.Equal(replayed.summary(kindsToCompare...), want) {
+		select {
+		case <-deadline:
+			t.Fatalf("expected replay to match the original\nwant %q\ngot  %q", want, replayed.summary(kindsToCompare...))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestReplay_Realtime(t *testing.T) {
+	room := echoRoom(t, nil)
+	gap := 50 * time.Millisecond
+	start := time.Now()
+	records := []Record[string]{
+		{Time: start, Kind: RecordConnect, Player: "alice"},
+		{Time: start.Add(gap), Kind: RecordStatus, Status: Locked},
+	}
+	if err := Replay(context.Background(), room, records, ReplayOptions{Realtime: true}); err != nil {
+		t.Fatalf("Replay returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < gap {
+		t.Errorf("expected replay to take at least %v, took %v", gap, elapsed)
+	}
+	if room.Status != Locked {
+		t.Errorf("expected the room to be locked, got %v", room.Status)
+	}
+}
+
+func TestReplay_UnknownPlayer(t *testing.T) {
+	room := echoRoom(t, nil)
+	err := Replay(context.Background(), room, []Record[string]{{Kind: RecordInbound, Player: "ghost"}}, ReplayOptions{})
+	if err == nil {
+		t.Fatal("expected an error for a message from a player who never connected")
+	}
+}
+
+func TestRoom_RecordEvents(t *testing.T) {
+	rec := &sliceRecorder{}
+	room := echoRoom(t, rec)
+	room.publish(EventType(99), "alice")
+	room.deliverEvents()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.records) != 0 {
+		t.Errorf("expected an event without a record kind not to be recorded, got %+v", rec.records)
+	}
+}
+
+func TestNewFileRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "room.rec")
+	for i := range 2 {
+		rec, err := NewFileRecorder[int](path)
+		if err != nil {
+			t.Fatalf("NewFileRecorder returned error: %v", err)
+		}
+		rec.Record(Record[int]{Kind: RecordInbound, Player: i + 1, Payload: []byte("hi")})
+		if err := rec.Close(); err != nil {
+			t.Fatalf("Close returned error: %v", err)
+		}
+	}
+
+	records, err := ReadRecordingFile[int](path)
+	if err != nil {
+		t.Fatalf("ReadRecordingFile returned error: %v", err)
+	}
+	if len(records) != 2 || records[0].Player != 1 || records[1].Player != 2 || string(records[1].Payload) != "hi" {
+		t.Errorf("expected the second recorder to append, got %+v", records)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type multiRecorder []Recorder[string]
+
+func (m multiRecorder) Record(rec Record[string]) {
+	for _, r := range m {
+		r.Record(rec)
+	}
+}
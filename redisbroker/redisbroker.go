@@ -0,0 +1,449 @@
+// Package redisbroker is a goroom.Broker over Redis publish/subscribe, so that a room can have players connected to
+// several processes. It speaks the Redis protocol directly and has no dependencies beyond the standard library.
+package redisbroker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/chilledoj/goroom"
+)
+
+var ErrClosed = errors.New("redisbroker: closed")
+
+var _ goroom.Broker = (*Broker)(nil)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultMinBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff  = 10 * time.Second
+	handlerBuffer      = 1024
+)
+
+// Options configures a Broker. Every field is optional.
+type Options struct {
+	// Username and Password authenticate each connection with AUTH. Set only Password for Redis before 6.
+	Username string
+	Password string
+	// DialTimeout bounds each connection attempt. Defaults to 5 seconds.
+	DialTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the jittered exponential backoff between attempts to restore the subscription
+	// connection. They default to 100ms and 10s. Messages published while it is down are not received.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Slogger defaults to slog.Default().
+	Slogger *slog.Logger
+}
+
+// Broker publishes on one connection to Redis and receives every subscription on another, which it reconnects if
+// it drops. It is safe for concurrent use.
+type Broker struct {
+	addr string
+	opts Options
+	sl   *slog.Logger
+
+	pubMu sync.Mutex
+	pub   *conn
+
+	mu        sync.Mutex
+	sub       *conn
+	running   bool
+	closed    bool
+	handlers  map[string]map[*handler]struct{}
+	confirmed map[string]bool
+	pending   map[string][]chan error
+
+	done chan struct{}
+}
+
+type conn struct {
+	nc net.Conn
+	br *bufio.Reader
+	bw *bufio.Writer
+}
+
+type handler struct {
+	ch   chan []byte
+	done chan struct{}
+}
+
+// New returns a Broker for the Redis server at addr, such as "localhost:6379". It connects when first used.
+func New(addr string, opts Options) *Broker {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+	sl := opts.Slogger
+	if sl == nil {
+		sl = slog.Default()
+	}
+	return &Broker{
+		addr:      addr,
+		opts:      opts,
+		sl:        sl.With("redis", addr),
+		handlers:  make(map[string]map[*handler]struct{}),
+		confirmed: make(map[string]bool),
+		pending:   make(map[string][]chan error),
+		done:      make(chan struct{}),
+	}
+}
+
+// Publish publishes payload on the Redis channel named topic.
+func (b *Broker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	if b.isClosed() {
+		return ErrClosed
+	}
+	if b.pub == nil {
+		c, err := b.dial(ctx)
+		if err != nil {
+			return err
+		}
+		b.pub = c
+	}
+	deadline, _ := ctx.Deadline()
+	_ = b.pub.nc.SetDeadline(deadline)
+	reply, err := b.pub.do("PUBLISH", topic, string(payload))
+	if err != nil {
+		// The connection is in an unknown state, so the next Publish dials a new one.
+		_ = b.pub.nc.Close()
+		b.pub = nil
+		return err
+	}
+	if rerr, ok := reply.(redisError); ok {
+		return rerr
+	}
+	return nil
+}
+
+// Subscribe calls handle with each payload published on the Redis channel named topic, waiting for Redis to
+// confirm the subscription. If the subscription connection is being restored, it returns straight away and the
+// topic is subscribed once it is back.
+func (b *Broker) Subscribe(ctx context.Context, topic string, handle func(payload []byte)) (func(), error) {
+	h := &handler{ch: make(chan []byte, handlerBuffer), done: make(chan struct{})}
+	if err := b.startSubscriber(ctx); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	first := len(b.handlers[topic]) == 0
+	if first {
+		b.handlers[topic] = make(map[*handler]struct{})
+	}
+	b.handlers[topic][h] = struct{}{}
+	var confirm chan error
+	if !b.confirmed[topic] && b.sub != nil {
+		confirm = make(chan error, 1)
+		b.pending[topic] = append(b.pending[topic], confirm)
+		if first {
+			if err := b.sub.send("SUBSCRIBE", topic); err != nil {
+				// The reader sees the broken connection too and restores the subscription.
+				b.sl.Debug("subscribe failed", "topic", topic, "err", err)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case payload := <-h.ch:
+				handle(payload)
+			case <-h.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	unsubscribe := func() { once.Do(func() { b.unsubscribe(topic, h) }) }
+	if confirm != nil {
+		select {
+		case err := <-confirm:
+			if err != nil {
+				unsubscribe()
+				return nil, err
+			}
+		case <-ctx.Done():
+			unsubscribe()
+			return nil, ctx.Err()
+		}
+	}
+	return unsubscribe, nil
+}
+
+// startSubscriber opens the subscription connection, unless it is already running. It dials without holding b.mu,
+// so that a slow server does not hold up the other subscriptions, and drops its connection if another won the race.
+func (b *Broker) startSubscriber(ctx context.Context) error {
+	b.mu.Lock()
+	closed, running := b.closed, b.running
+	b.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if running {
+		return nil
+	}
+	c, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed || b.running {
+		_ = c.nc.Close()
+		if b.closed {
+			return ErrClosed
+		}
+		return nil
+	}
+	b.sub, b.running = c, true
+	go b.run(c)
+	return nil
+}
+
+func (b *Broker) unsubscribe(topic string, h *handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		// Close has already ended every handler.
+		return
+	}
+	close(h.done)
+	delete(b.handlers[topic], h)
+	if len(b.handlers[topic]) > 0 {
+		return
+	}
+	delete(b.handlers, topic)
+	delete(b.confirmed, topic)
+	if b.sub != nil {
+		if err := b.sub.send("UNSUBSCRIBE", topic); err != nil {
+			b.sl.Debug("unsubscribe failed", "topic", topic, "err", err)
+		}
+	}
+}
+
+// Close closes the broker's connections and ends every subscription.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	if b.sub != nil {
+		_ = b.sub.nc.Close()
+	}
+	b.failPending(ErrClosed)
+	for _, hs := range b.handlers {
+		for h := range hs {
+			close(h.done)
+		}
+	}
+	b.handlers = nil
+	b.mu.Unlock()
+
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	if b.pub != nil {
+		_ = b.pub.nc.Close()
+		b.pub = nil
+	}
+	return nil
+}
+
+func (b *Broker) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// run reads from the subscription connection, restoring it and its subscriptions whenever it drops.
+func (b *Broker) run(c *conn) {
+	for {
+		err := b.read(c)
+		_ = c.nc.Close()
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		b.sl.Warn("subscription connection lost", "err", err)
+		b.sub = nil
+		clear(b.confirmed)
+		b.failPending(err)
+		b.mu.Unlock()
+
+		if c = b.reconnect(); c == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials until it restores the subscription connection, returning nil if the broker closes first.
+func (b *Broker) reconnect() *conn {
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-time.After(b.backoff(attempt)):
+		case <-b.done:
+			return nil
+		}
+		c, err := b.dial(context.Background())
+		if err != nil {
+			b.sl.Debug("reconnect failed", "attempt", attempt+1, "err", err)
+			continue
+		}
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			_ = c.nc.Close()
+			return nil
+		}
+		topics := make([]string, 0, len(b.handlers))
+		for topic := range b.handlers {
+			topics = append(topics, topic)
+		}
+		if len(topics) > 0 {
+			err = c.send(append([]string{"SUBSCRIBE"}, topics...)...)
+		}
+		if err != nil {
+			b.mu.Unlock()
+			_ = c.nc.Close()
+			continue
+		}
+		b.sub = c
+		b.mu.Unlock()
+		b.sl.Info("subscription connection restored", "topics", len(topics))
+		return c
+	}
+}
+
+// backoff returns a jittered exponential delay for the attempt.
+func (b *Broker) backoff(attempt int) time.Duration {
+	d := b.opts.MinBackoff << min(attempt, 30)
+	if d <= 0 || d > b.opts.MaxBackoff {
+		d = b.opts.MaxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// read dispatches pushes from the subscription connection until it fails.
+func (b *Broker) read(c *conn) error {
+	for {
+		reply, err := readReply(c.br)
+		if err != nil {
+			return err
+		}
+		push, ok := reply.([]any)
+		if !ok || len(push) < 2 {
+			if rerr, ok := reply.(redisError); ok {
+				return rerr
+			}
+			continue
+		}
+		kind, topic := text(push[0]), text(push[1])
+		switch kind {
+		case "message":
+			if len(push) == 3 {
+				payload, _ := push[2].([]byte)
+				b.dispatch(topic, payload)
+			}
+		case "subscribe":
+			b.mu.Lock()
+			if len(b.handlers[topic]) > 0 {
+				b.confirmed[topic] = true
+			}
+			for _, confirm := range b.pending[topic] {
+				confirm <- nil
+			}
+			delete(b.pending, topic)
+			b.mu.Unlock()
+		}
+	}
+}
+
+// dispatch hands the payload to every handler of the topic, waiting for any that has fallen far behind.
+func (b *Broker) dispatch(topic string, payload []byte) {
+	b.mu.Lock()
+	hs := make([]*handler, 0, len(b.handlers[topic]))
+	for h := range b.handlers[topic] {
+		hs = append(hs, h)
+	}
+	b.mu.Unlock()
+	for _, h := range hs {
+		select {
+		case h.ch <- payload:
+		case <-h.done:
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// failPending fails every Subscribe waiting for confirmation. The caller must hold b.mu.
+func (b *Broker) failPending(err error) {
+	for _, waiting := range b.pending {
+		for _, confirm := range waiting {
+			confirm <- err
+		}
+	}
+	clear(b.pending)
+}
+
+func (b *Broker) dial(ctx context.Context) (*conn, error) {
+	d := net.Dialer{Timeout: b.opts.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", b.addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{nc: nc, br: bufio.NewReader(nc), bw: bufio.NewWriter(nc)}
+	if b.opts.Password == "" {
+		return c, nil
+	}
+	args := []string{"AUTH", b.opts.Password}
+	if b.opts.Username != "" {
+		args = []string{"AUTH", b.opts.Username, b.opts.Password}
+	}
+	deadline, _ := ctx.Deadline()
+	_ = nc.SetDeadline(deadline)
+	reply, err := c.do(args...)
+	if err == nil {
+		if rerr, ok := reply.(redisError); ok {
+			err = rerr
+		}
+	}
+	if err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	_ = nc.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func (c *conn) send(args ...string) error {
+	return writeCommand(c.bw, args...)
+}
+
+func (c *conn) do(args ...string) (any, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return readReply(c.br)
+}
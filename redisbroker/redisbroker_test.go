@@ -0,0 +1,349 @@
+package redisbroker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chilledoj/goroom"
+)
+
+// fakeRedis is a stand-in for a Redis server that implements just enough of the protocol for publish/subscribe.
+type fakeRedis struct {
+	ln       net.Listener
+	password string
+
+	mu    sync.Mutex
+	conns map[*fakeConn]struct{}
+	subs  map[string]map[*fakeConn]struct{}
+}
+
+type fakeConn struct {
+	nc net.Conn
+	mu sync.Mutex
+	bw *bufio.Writer
+}
+
+func (c *fakeConn) write(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(c.bw, format, args...)
+	_ = c.bw.Flush()
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{
+		ln:       ln,
+		password: password,
+		conns:    make(map[*fakeConn]struct{}),
+		subs:     make(map[string]map[*fakeConn]struct{}),
+	}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c := &fakeConn{nc: nc, bw: bufio.NewWriter(nc)}
+			s.mu.Lock()
+			s.conns[c] = struct{}{}
+			s.mu.Unlock()
+			go s.serve(c)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		s.dropConns()
+	})
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.ln.Addr().String()
+}
+
+// dropConns closes every client connection, as if the server had restarted.
+func (s *fakeRedis) dropConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		_ = c.nc.Close()
+	}
+}
+
+func (s *fakeRedis) serve(c *fakeConn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		for _, subs := range s.subs {
+			delete(subs, c)
+		}
+		s.mu.Unlock()
+		_ = c.nc.Close()
+	}()
+	br := bufio.NewReader(c.nc)
+	for {
+		reply, err := readReply(br)
+		if err != nil {
+			return
+		}
+		values, _ := reply.([]any)
+		args := make([]string, len(values))
+		for i, v := range values {
+			args[i] = text(v)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "AUTH":
+			if args[len(args)-1] != s.password {
+				c.write("-WRONGPASS invalid password\r\n")
+				continue
+			}
+			c.write("+OK\r\n")
+		case "PUBLISH":
+			s.mu.Lock()
+			subs := make([]*fakeConn, 0, len(s.subs[args[1]]))
+			for sub := range s.subs[args[1]] {
+				subs = append(subs, sub)
+			}
+			s.mu.Unlock()
+			for _, sub := range subs {
+				sub.write("*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			c.write(":%d\r\n", len(subs))
+		case "SUBSCRIBE", "UNSUBSCRIBE":
+			kind := "subscribe"
+			if args[0] == "UNSUBSCRIBE" {
+				kind = "unsubscribe"
+			}
+			for _, topic := range args[1:] {
+				s.mu.Lock()
+				if kind == "subscribe" {
+					if s.subs[topic] == nil {
+						s.subs[topic] = make(map[*fakeConn]struct{})
+					}
+					s.subs[topic][c] = struct{}{}
+				} else {
+					delete(s.subs[topic], c)
+				}
+				s.mu.Unlock()
+				c.write("*3\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:1\r\n", len(kind), kind, len(topic), topic)
+			}
+		default:
+			c.write("-ERR unknown command '%s'\r\n", args[0])
+		}
+	}
+}
+
+func newBroker(t *testing.T, addr string, opts Options) *Broker {
+	t.Helper()
+	b := New(addr, opts)
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func expectPayload(t *testing.T, got <-chan string, want string) {
+	t.Helper()
+	select {
+	case msg := <-got:
+		if msg != want {
+			t.Fatalf("expected '%s', got '%s'", want, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for '%s'", want)
+	}
+}
+
+// testPubSub checks that messages published by pub reach sub in order until it unsubscribes.
+func testPubSub(t *testing.T, pub, sub *Broker) {
+	ctx := context.Background()
+	got := make(chan string, 10)
+	unsubscribe, err := sub.Subscribe(ctx, "goroom.test", func(payload []byte) { got <- string(payload) })
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	for _, msg := range []string{"one", "two", "three\r\nwith a newline"} {
+		if err := pub.Publish(ctx, "goroom.test", []byte(msg)); err != nil {
+			t.Fatalf("Publish returned error: %v", err)
+		}
+	}
+	expectPayload(t, got, "one")
+	expectPayload(t, got, "two")
+	expectPayload(t, got, "three\r\nwith a newline")
+
+	unsubscribe()
+	unsubscribe()
+	if err := pub.Publish(ctx, "goroom.test", []byte("late")); err != nil {
+		t.Fatalf("Publish returned error: %v", err)
+	}
+	select {
+	case msg := <-got:
+		t.Errorf("expected nothing after unsubscribing, got '%s'", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBroker(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	testPubSub(t, newBroker(t, srv.addr(), Options{}), newBroker(t, srv.addr(), Options{}))
+}
+
+func TestBroker_Auth(t *testing.T) {
+	srv := newFakeRedis(t, "secret")
+	if err := newBroker(t, srv.addr(), Options{Password: "wrong"}).Publish(context.Background(), "t", nil); err == nil {
+		t.Error("expected an error with the wrong password")
+	}
+	if err := newBroker(t, srv.addr(), Options{Password: "secret"}).Publish(context.Background(), "t", nil); err != nil {
+		t.Errorf("Publish returned error: %v", err)
+	}
+}
+
+func TestBroker_Reconnect(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	b := newBroker(t, srv.addr(), Options{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	got := make(chan string, 100)
+	if _, err := b.Subscribe(context.Background(), "t", func(payload []byte) { got <- string(payload) }); err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	srv.dropConns()
+
+	// Messages published before the subscription is restored are lost, so keep publishing until one arrives.
+	deadline := time.After(time.Second)
+	for {
+		_ = b.Publish(context.Background(), "t", []byte("again"))
+		select {
+		case msg := <-got:
+			if msg != "again" {
+				t.Fatalf("expected 'again', got '%s'", msg)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for the subscription to be restored")
+		}
+	}
+}
+
+func TestBroker_ConcurrentSubscribe(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	b := newBroker(t, srv.addr(), Options{})
+	got := make(chan string, 10)
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			topic := fmt.Sprintf("t%d", i)
+			if _, err := b.Subscribe(context.Background(), topic, func(payload []byte) { got <- string(payload) }); err != nil {
+				t.Errorf("Subscribe returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range 10 {
+		if err := b.Publish(context.Background(), fmt.Sprintf("t%d", i), []byte("hello")); err != nil {
+			t.Fatalf("Publish returned error: %v", err)
+		}
+		expectPayload(t, got, "hello")
+	}
+
+	// The subscribers that lost the race to dial close their connections, leaving one to subscribe and one to publish.
+	deadline := time.Now().Add(time.Second)
+	for {
+		srv.mu.Lock()
+		conns := len(srv.conns)
+		srv.mu.Unlock()
+		if conns == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 connections, got %d", conns)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	b := New(srv.addr(), Options{})
+	unsubscribe, err := b.Subscribe(context.Background(), "t", func([]byte) {})
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %v", err)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	unsubscribe()
+	if err := b.Publish(context.Background(), "t", nil); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Publish, got %v", err)
+	}
+	if _, err := b.Subscribe(context.Background(), "t", func([]byte) {}); err != ErrClosed {
+		t.Errorf("expected ErrClosed from Subscribe, got %v", err)
+	}
+}
+
+func TestBroker_Rooms(t *testing.T) {
+	srv := newFakeRedis(t, "")
+	rooms := make([]*goroom.Room[string, string], 2)
+	for i := range rooms {
+		room, err := goroom.NewRoom[string, string](context.Background(), "lobby", goroom.Options[string]{
+			Broker: newBroker(t, srv.addr(), Options{}),
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		t.Cleanup(room.Stop)
+		rooms[i] = room
+	}
+	alice, err := rooms[0].ConnectMemory(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := rooms[1].ConnectMemory(context.Background(), "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !rooms[0].GetPlayerPresence("bob").IsConnected || !rooms[1].GetPlayerPresence("alice").IsConnected {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the rooms to share presence")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	rooms[0].SendMessageToAllPlayers([]byte("hello"))
+	for _, c := range []*goroom.MemoryClient[string]{alice, bob} {
+		select {
+		case msg := <-c.Receive():
+			if string(msg) != "hello" {
+				t.Errorf("expected 'hello', got '%s'", msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the message")
+		}
+	}
+}
+
+// TestBroker_Redis runs against a real Redis server when REDIS_ADDR is set.
+func TestBroker_Redis(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_ADDR not set")
+	}
+	opts := Options{Password: os.Getenv("REDIS_PASSWORD")}
+	testPubSub(t, newBroker(t, addr, opts), newBroker(t, addr, opts))
+}
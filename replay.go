@@ -0,0 +1,140 @@
+package goroom
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ReplayOptions configures Replay.
+type ReplayOptions struct {
+	// Realtime waits between records for as long as separated them in the recording, using the room's Clock.
+	// By default records are replayed as fast as possible.
+	Realtime bool
+	// SyncTimeout is how long, on the room's Clock, Replay waits for the recipient of each recorded outbound message
+	// to receive a message, which keeps the replay in step with the room's callbacks. If nothing arrives in time,
+	// perhaps because the room has diverged from the recording, Replay carries on regardless. Defaults to one second.
+	SyncTimeout time.Duration
+}
+
+const defaultSyncTimeout = time.Second
+
+// Replay feeds a recording into a room, usually a fresh one, so that a bug can be reproduced. Each recorded player
+// connects over an in-process connection and sends their recorded inbound messages, and recorded status changes are
+// applied to the room. Outbound messages and removals are not replayed, as the room produces them itself; record
+// the replayed room to compare them with the original. Outbound messages are instead used to keep in step; see
+// ReplayOptions.SyncTimeout.
+//
+// Replay returns when the recording has been fed in, leaving connected players connected until the room stops. The
+// room must be started.
+func Replay[RoomId comparable, PlayerID comparable](ctx context.Context, room *Room[RoomId, PlayerID], records []Record[PlayerID], opts ReplayOptions) error {
+	// Every connect and disconnect in the recording publishes at most one event, so the buffer never fills.
+	events, unsubscribe := room.Subscribe(len(records) + 1)
+	defer unsubscribe()
+
+	if opts.SyncTimeout <= 0 {
+		opts.SyncTimeout = defaultSyncTimeout
+	}
+	clients := make(map[PlayerID]*MemoryClient[PlayerID])
+	// Messages to players still connected at the end are discarded so that their buffers do not fill.
+	defer func() {
+		for _, c := range clients {
+			go discard(c.Receive())
+		}
+	}()
+	for i, rec := range records {
+		if opts.Realtime && i > 0 {
+			if err := room.sleep(ctx, rec.Time.Sub(records[i-1].Time)); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		switch rec.Kind {
+		case RecordConnect:
+			var c *MemoryClient[PlayerID]
+			if c, err = room.ConnectMemory(ctx, rec.Player); err == nil {
+				clients[rec.Player] = c
+			}
+		case RecordInbound:
+			c, ok := clients[rec.Player]
+			if !ok {
+				err = ErrNotConnected
+				break
+			}
+			err = c.Send(rec.Payload)
+		case RecordOutbound:
+			if c, ok := clients[rec.Player]; ok {
+				err = room.awaitMessage(ctx, c, opts.SyncTimeout)
+			}
+		case RecordDisconnect:
+			c, ok := clients[rec.Player]
+			if !ok {
+				err = ErrNotConnected
+				break
+			}
+			c.Close()
+			delete(clients, rec.Player)
+			// The player can only reconnect once the room has handled the disconnect.
+			err = awaitEvent(ctx, events, EventPlayerDisconnected, rec.Player)
+		case RecordStatus:
+			room.SetStatus(rec.Status)
+		}
+		if err != nil {
+			return fmt.Errorf("replay record %d (%s %v): %w", i, rec.Kind, rec.Player, err)
+		}
+	}
+	return nil
+}
+
+// sleep waits for d on the room's clock.
+func (room *Room[RoomId, PlayerID]) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := room.clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func awaitEvent[RoomId comparable, PlayerID comparable](ctx context.Context, events <-chan Event[RoomId, PlayerID], eventType EventType, player PlayerID) error {
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return ErrNotConnected
+			}
+			if e.Type == eventType && e.Player == player {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// awaitMessage waits for the client to receive a message, for at most timeout on the room's clock.
+func (room *Room[RoomId, PlayerID]) awaitMessage(ctx context.Context, c *MemoryClient[PlayerID], timeout time.Duration) error {
+	timer := room.clock.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-c.Receive():
+	case <-timer.C():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func discard(ch <-chan []byte) {
+	for range ch {
+	}
+}
@@ -0,0 +1,83 @@
+package goroom
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var ErrRoomExists = errors.New("room already exists")
+
+// RoomManager is a registry of rooms for servers that host more than one, such as a lobby server. It does not start
+// or stop rooms itself.
+type RoomManager[RoomId comparable, PlayerID comparable] struct {
+	mu    sync.RWMutex
+	rooms map[RoomId]*Room[RoomId, PlayerID]
+}
+
+func NewRoomManager[RoomId comparable, PlayerID comparable]() *RoomManager[RoomId, PlayerID] {
+	return &RoomManager[RoomId, PlayerID]{
+		rooms: make(map[RoomId]*Room[RoomId, PlayerID]),
+	}
+}
+
+// Add registers the room under its ID. It returns ErrRoomExists if a room with the same ID is already registered.
+func (rm *RoomManager[RoomId, PlayerID]) Add(room *Room[RoomId, PlayerID]) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if _, ok := rm.rooms[room.ID]; ok {
+		return fmt.Errorf("%w: %v", ErrRoomExists, room.ID)
+	}
+	rm.rooms[room.ID] = room
+	return nil
+}
+
+func (rm *RoomManager[RoomId, PlayerID]) Get(id RoomId) (*Room[RoomId, PlayerID], bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	room, ok := rm.rooms[id]
+	return room, ok
+}
+
+// Remove unregisters the room with the given ID, returning it if it was registered.
+func (rm *RoomManager[RoomId, PlayerID]) Remove(id RoomId) (*Room[RoomId, PlayerID], bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	room, ok := rm.rooms[id]
+	delete(rm.rooms, id)
+	return room, ok
+}
+
+// Rooms returns the registered rooms ordered by the string form of their IDs.
+func (rm *RoomManager[RoomId, PlayerID]) Rooms() []*Room[RoomId, PlayerID] {
+	rm.mu.RLock()
+	rooms := make([]*Room[RoomId, PlayerID], 0, len(rm.rooms))
+	for _, room := range rm.rooms {
+		rooms = append(rooms, room)
+	}
+	rm.mu.RUnlock()
+	slices.SortFunc(rooms, func(a, b *Room[RoomId, PlayerID]) int {
+		return cmp.Compare(a.label(), b.label())
+	})
+	return rooms
+}
+
+func (rm *RoomManager[RoomId, PlayerID]) Len() int {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return len(rm.rooms)
+}
+
+// lookup finds a room by the string form of its ID, as used in URLs.
+func (rm *RoomManager[RoomId, PlayerID]) lookup(label string) (*Room[RoomId, PlayerID], bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	for _, room := range rm.rooms {
+		if room.label() == label {
+			return room, true
+		}
+	}
+	return nil, false
+}
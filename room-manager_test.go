@@ -0,0 +1,41 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRoomManager(t *testing.T) {
+	manager := NewRoomManager[string, string]()
+	for _, id := range []string{"b", "a"} {
+		room, err := NewRoom[string, string](context.Background(), id, Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		if err := manager.Add(room); err != nil {
+			t.Fatalf("Add returned error: %v", err)
+		}
+	}
+	duplicate, _ := NewRoom[string, string](context.Background(), "a", Options[string]{})
+	if err := manager.Add(duplicate); !errors.Is(err, ErrRoomExists) {
+		t.Errorf("expected ErrRoomExists, got %v", err)
+	}
+
+	rooms := manager.Rooms()
+	if len(rooms) != 2 || rooms[0].ID != "a" || rooms[1].ID != "b" {
+		t.Errorf("expected rooms ordered by ID, got %v", rooms)
+	}
+	if room, ok := manager.Get("a"); !ok || room.ID != "a" {
+		t.Errorf("expected to get room a, got %v %v", room, ok)
+	}
+	if _, ok := manager.Remove("a"); !ok {
+		t.Error("expected room a to be removed")
+	}
+	if _, ok := manager.Get("a"); ok {
+		t.Error("expected room a to be gone")
+	}
+	if manager.Len() != 1 {
+		t.Errorf("expected 1 room, got %d", manager.Len())
+	}
+}
@@ -1,5 +1,7 @@
 package goroom
 
+import "fmt"
+
 type RoomStatus int8
 
 const (
@@ -20,3 +22,13 @@ func (r RoomStatus) String() string {
 		return "Unknown"
 	}
 }
+
+// ParseRoomStatus is the inverse of RoomStatus.String.
+func ParseRoomStatus(s string) (RoomStatus, error) {
+	for _, status := range []RoomStatus{Inactive, Open, Locked} {
+		if status.String() == s {
+			return status, nil
+		}
+	}
+	return Inactive, fmt.Errorf("unknown room status %q", s)
+}
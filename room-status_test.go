@@ -51,3 +51,18 @@ func TestRoomStatusString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRoomStatus(t *testing.T) {
+	for _, status := range []RoomStatus{Inactive, Open, Locked} {
+		got, err := ParseRoomStatus(status.String())
+		if err != nil {
+			t.Errorf("ParseRoomStatus(%q) returned error: %v", status, err)
+		}
+		if got != status {
+			t.Errorf("ParseRoomStatus(%q) = %v", status, got)
+		}
+	}
+	if _, err := ParseRoomStatus("Unknown"); err == nil {
+		t.Error("expected error for unknown status")
+	}
+}
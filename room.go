@@ -2,6 +2,8 @@ package goroom
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"slices"
 	"sync"
@@ -25,33 +27,70 @@ type Room[RoomId comparable, PlayerID comparable] struct {
 	lastSeen      map[PlayerID]time.Time
 	cleanupPeriod time.Duration
 
+	// Callbacks, normalised to their context-carrying form
+	onConnect    func(ctx context.Context, player PlayerID)
+	onDisconnect func(ctx context.Context, player PlayerID)
+	onRemove     func(ctx context.Context, player PlayerID)
+	onMessage    func(ctx context.Context, player PlayerID, message []byte)
+	contexts     map[PlayerID]playerContext
+
 	// MessageProcessing
-	messages chan SocketMessage[PlayerID]
+	messages     chan SocketMessage[PlayerID]
+	throttle     throttleCounters
+	middlewares  []Middleware[RoomId, PlayerID]
+	handler      Handler[RoomId, PlayerID]
+	interceptors []OutboundInterceptor[PlayerID]
 
 	// Concurrency
 	ctx    context.Context
 	cancel context.CancelFunc
 	//wg     sync.WaitGroup
+	callbacks callbackGroup
+	draining  bool
+	stopped   bool
+	stopOnce  sync.Once
+	// loopDone is closed when Start returns and done once the room has fully stopped.
+	loopDone chan struct{}
+	done     chan struct{}
 
 	// Logging
 	Slogger *slog.Logger
-}
 
-type Options[PlayerID comparable] struct {
-	OnConnect    func(player PlayerID)
-	OnDisconnect func(player PlayerID)
-	OnRemove     func(player PlayerID)
-	OnMessage    func(player PlayerID, message []byte)
+	// Observers
+	events eventBus[RoomId, PlayerID]
+	// pending holds the events published under room.mu until deliverEvents, which deliverMu serialises.
+	pendingMu sync.Mutex
+	pending   []Event[RoomId, PlayerID]
+	deliverMu sync.Mutex
 
-	IgnoreCleanup bool
-	CleanupPeriod time.Duration
+	// Metrics
+	metrics      *roomMetrics
+	metricsLabel string
+	tracer       Tracer
 
-	Slogger *slog.Logger
+	clock Clock
+
+	// snapshotMu is held while a snapshot is saved; snapshotsDone is set, under it, once the final one is.
+	snapshotMu    sync.Mutex
+	snapshotsDone bool
+
+	// cluster shares the room with other nodes when Options.Broker is set.
+	cluster *cluster[RoomId, PlayerID]
 }
 
 const defaultCleanupPeriod time.Duration = time.Second * 30
 
-func NewRoom[RoomId comparable, PlayerID comparable](parentCtx context.Context, id RoomId, options Options[PlayerID]) *Room[RoomId, PlayerID] {
+var (
+	ErrRoomStarted = errors.New("room already started")
+	ErrRoomStopped = errors.New("room stopped")
+)
+
+// NewRoom creates a room ready to be started. It returns an error wrapping ErrInvalidOptions if the options are
+// inconsistent.
+func NewRoom[RoomId comparable, PlayerID comparable](parentCtx context.Context, id RoomId, options Options[PlayerID]) (*Room[RoomId, PlayerID], error) {
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithCancel(parentCtx)
 	room := &Room[RoomId, PlayerID]{
 		ID:        id,
@@ -63,26 +102,56 @@ func NewRoom[RoomId comparable, PlayerID comparable](parentCtx context.Context,
 		cancel:    cancel,
 		lastSeen:  make(map[PlayerID]time.Time),
 		isStarted: false,
+		contexts:  make(map[PlayerID]playerContext),
+		loopDone:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	room.onConnect = withContext(options.OnConnect, options.OnConnectContext)
+	room.onDisconnect = withContext(options.OnDisconnect, options.OnDisconnectContext)
+	room.onRemove = withContext(options.OnRemove, options.OnRemoveContext)
+	room.onMessage = options.OnMessageContext
+	if room.onMessage == nil && options.OnMessage != nil {
+		room.onMessage = func(_ context.Context, player PlayerID, message []byte) {
+			options.OnMessage(player, message)
+		}
 	}
 	if options.CleanupPeriod == 0 {
 		room.cleanupPeriod = defaultCleanupPeriod
 	} else {
 		room.cleanupPeriod = options.CleanupPeriod
 	}
+	room.handler = room.dispatchMessage
+	metrics := options.Metrics
+	if metrics == nil {
+		metrics = NopMetrics{}
+	}
+	room.metrics = &roomMetrics{Metrics: metrics}
+	room.metricsLabel = fmt.Sprint(room.ID)
+	room.tracer = options.Tracer
+	if room.tracer == nil {
+		room.tracer = NopTracer{}
+	}
+	room.clock = options.Clock
+	if room.clock == nil {
+		room.clock = RealClock{}
+	}
 
-	if options.Slogger != nil {
-		room.Slogger = options.Slogger.With("room", room.ID)
-	} else {
-		room.Slogger = slog.Default().With("room", room.ID)
+	room.Slogger = room.newLogger()
+	if options.Broker != nil {
+		room.cluster = newCluster(room, options)
 	}
 
-	return room
+	return room, nil
 }
 
+// GetPlayerPresences returns the presence of every player in the room, including those on other nodes when the
+// room has a Broker.
 func (room *Room[RoomId, PlayerID]) GetPlayerPresences() []PlayerPresence[PlayerID] {
 	room.mu.RLock()
 	playerPresences := make([]PlayerPresence[PlayerID], 0, len(room.players))
+	local := make(map[PlayerID]int, len(room.players))
 	for playerID, p := range room.players {
+		local[playerID] = len(playerPresences)
 		playerPresences = append(playerPresences, PlayerPresence[PlayerID]{
 			ID:          playerID,
 			IsConnected: p != nil,
@@ -90,28 +159,66 @@ func (room *Room[RoomId, PlayerID]) GetPlayerPresences() []PlayerPresence[Player
 		})
 	}
 	room.mu.RUnlock()
+	if room.cluster == nil {
+		return playerPresences
+	}
+	for _, remote := range room.cluster.presences() {
+		i, ok := local[remote.ID]
+		if !ok {
+			playerPresences = append(playerPresences, remote)
+		} else if !playerPresences[i].IsConnected && (remote.IsConnected || remote.LastSeen.After(playerPresences[i].LastSeen)) {
+			playerPresences[i] = remote
+		}
+	}
 	return playerPresences
 }
 
+// GetPlayerPresence returns the player's presence, on any node when the room has a Broker.
 func (room *Room[RoomId, PlayerID]) GetPlayerPresence(playerID PlayerID) PlayerPresence[PlayerID] {
 	room.mu.RLock()
-	connP := room.players[playerID]
-	room.mu.RUnlock()
-	return PlayerPresence[PlayerID]{
+	presence := PlayerPresence[PlayerID]{
 		ID:          playerID,
-		IsConnected: connP != nil,
+		IsConnected: room.players[playerID] != nil,
 		LastSeen:    room.lastSeen[playerID],
 	}
+	room.mu.RUnlock()
+	if room.cluster == nil || presence.IsConnected {
+		return presence
+	}
+	if remote, ok := room.cluster.presence(playerID); ok && (remote.IsConnected || remote.LastSeen.After(presence.LastSeen)) {
+		return remote
+	}
+	return presence
 }
 
-func (room *Room[RoomId, PlayerID]) Start() {
+// Start runs the room until it is stopped, so it is usually called on its own goroutine. It returns
+// ErrRoomStarted if the room is already running and ErrRoomStopped if it has been stopped, as a room cannot be
+// restarted. With a Broker, it first subscribes to the room's topic and returns the error if that fails.
+func (room *Room[RoomId, PlayerID]) Start() error {
 	room.mu.Lock()
+	if room.stopped {
+		room.mu.Unlock()
+		return ErrRoomStopped
+	}
 	if room.isStarted {
 		room.mu.Unlock()
-		return
+		return ErrRoomStarted
 	}
 	room.isStarted = true
 	room.mu.Unlock()
+	if room.cluster != nil {
+		if err := room.cluster.start(); err != nil {
+			room.mu.Lock()
+			room.isStarted = false
+			if room.stopped {
+				// Stop ran while the room was starting, and waits for the loop.
+				close(room.loopDone)
+			}
+			room.mu.Unlock()
+			return err
+		}
+	}
+	defer close(room.loopDone)
 
 	sl := room.Slogger.With("func", "room.Start")
 	sl.Debug("starting")
@@ -121,52 +228,111 @@ func (room *Room[RoomId, PlayerID]) Start() {
 		// excessively large time frame
 		period = time.Hour * 24
 	}
-	ticker := time.NewTicker(period)
+	ticker := room.clock.NewTicker(period)
+	var snapshots <-chan time.Time
+	if room.opts.SnapshotInterval > 0 {
+		snapshotTicker := room.clock.NewTicker(room.opts.SnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshots = snapshotTicker.C()
+	}
+	room.metrics.RoomStarted(room.metricsLabel)
 	defer func() {
 		ticker.Stop()
+		room.metrics.RoomStopped(room.metricsLabel)
 		sl.Info("stopped")
 	}()
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
 			sl.Debug("Cleaning up players")
 			room.CleanUpPlayers()
+		case <-snapshots:
+			room.goSaveSnapshot()
 		case <-room.ctx.Done():
 			sl.Debug("stopping")
-			return
+			return nil
 		case msg := <-room.messages:
-			sl.Debug("message", "type", msg.Type, "contents", msg.Message)
+			sl.Debug("message", "type", msg.Type, room.opts.LogPayloads.attr(msg.Message))
+			room.metrics.QueueDepth(room.metricsLabel, len(room.messages))
 			switch msg.Type {
 			case Disconnect:
 				sl.Debug("disconnecting", "player", msg.ReferenceID)
+				pid := msg.ReferenceID
 				room.mu.Lock()
-				room.players[msg.ReferenceID] = nil
-				room.lastSeen[msg.ReferenceID] = time.Now()
-				room.mu.Unlock()
-				sl.Debug("disconnected", "player", msg.ReferenceID)
-				go room.opts.OnDisconnect(msg.ReferenceID)
+				if room.stopped {
+					// Stop has already disconnected every player connected when it ran.
+					room.mu.Unlock()
+					continue
+				}
+				ctx := room.disconnect(pid, room.clock.Now())
+				// Hold a callback count from before the disconnect is visible until OnDisconnect is counted, so
+				// that Drain cannot see the room empty and stop waiting in between.
+				room.callbacks.add()
+				room.unlock()
+				sl.Debug("disconnected", "player", pid)
+				if room.onDisconnect != nil {
+					room.goCallback(ctx, "OnDisconnect", pid, func(ctx context.Context) { room.onDisconnect(ctx, pid) })
+				}
+				room.callbacks.done()
 
 			case Message:
 				sl.Debug("message", "player", msg.ReferenceID)
-				go room.opts.OnMessage(msg.ReferenceID, msg.Message)
+				pid, message, span := msg.ReferenceID, msg.Message, msg.span
+				room.record(RecordInbound, pid, 0, message)
+				ctx := msg.ctx
+				if ctx == nil {
+					ctx = room.ctx
+				}
+				room.goCallback(ctx, "OnMessage", pid, func(ctx context.Context) {
+					if span != nil {
+						defer span.End()
+					}
+					room.handleMessage(ctx, pid, message)
+				})
 			}
 		}
 	}
 }
 
+// Stop disconnects every player and stops the room. Each player still connected is disconnected by Stop itself,
+// with OnDisconnect and EventPlayerDisconnected, and the disconnects their sessions then report are ignored, as are
+// any messages the room has not yet handled. It is safe to call more than once and from any goroutine, including
+// the room's callbacks; calls after the first do nothing. Use Wait to also wait for in-flight callbacks.
 func (room *Room[RoomId, PlayerID]) Stop() {
+	room.stopOnce.Do(room.stop)
+}
+
+func (room *Room[RoomId, PlayerID]) stop() {
 	sl := room.Slogger.With("func", "room.Stop")
 	sl.Debug("closing", "status", "started")
-	room.mu.RLock()
-	playersToClose := make([]PlayerID, 0, len(room.players))
+	// Snapshot before the players are disconnected so that they are recorded as last seen now.
+	room.saveFinalSnapshot()
 
-	for playerID := range room.players {
-		playersToClose = append(playersToClose, playerID)
+	room.mu.Lock()
+	room.stopped = true
+	started := room.isStarted
+	playersToClose := make(map[PlayerID]SocketSessioner[PlayerID], len(room.players))
+	now := room.clock.Now()
+	for playerID, playerConn := range room.players {
+		playersToClose[playerID] = playerConn
+		if playerConn == nil {
+			continue
+		}
+		// The loop may stop before it sees these disconnects, so handle them here.
+		ctx := room.disconnect(playerID, now)
+		if room.onDisconnect != nil {
+			room.goCallback(ctx, "OnDisconnect", playerID, func(ctx context.Context) { room.onDisconnect(ctx, playerID) })
+		}
 	}
-	room.mu.RUnlock()
-	for _, playerID := range playersToClose {
+	room.unlock()
+
+	if !started {
+		// Nothing is reading the sessions' disconnects, so stop the sessions waiting to send them.
+		room.cancel()
+		room.metrics.stop()
+	}
+	for playerID, playerConn := range playersToClose {
 		sl.Debug("closing player", "player", playerID)
-		playerConn := room.players[playerID]
 		if playerConn == nil {
 			sl.Debug("player already closed", "player", playerID)
 			continue
@@ -174,81 +340,250 @@ func (room *Room[RoomId, PlayerID]) Stop() {
 		playerConn.Close() // should be blocking
 		sl.Debug("closed player", "player", playerID)
 	}
-	close(room.messages)
+	if room.cluster != nil {
+		room.cluster.stop()
+	}
 	room.cancel()
-	sl.Debug("room closed", "status", "completed")
+	var zero PlayerID
+	room.publish(EventRoomStopped, zero)
+	room.deliverEvents()
+	room.events.close()
 	room.mu.Lock()
 	room.isStarted = false
 	room.mu.Unlock()
+
+	go func() {
+		if started {
+			<-room.loopDone
+		}
+		<-room.callbacks.wait()
+		close(room.done)
+		sl.Debug("room closed", "status", "completed")
+	}()
 }
 
-func (room *Room[RoomId, PlayerID]) SendMessageToPlayer(player PlayerID, message []byte) {
-	sl := room.Slogger.With("func", "room.SendMessageToPlayer")
-	sl.Debug("sending message", "player", player, "message", message)
+// Done is closed once the room has fully stopped: Stop has been called, Start has returned and every callback
+// has finished.
+func (room *Room[RoomId, PlayerID]) Done() <-chan struct{} {
+	return room.done
+}
+
+// Wait blocks until the room has fully stopped, as signalled by Done. It must not be called from the room's own
+// callbacks, which it waits for.
+func (room *Room[RoomId, PlayerID]) Wait() {
+	<-room.done
+}
+
+// label is the string form of the room ID used in metrics and URLs.
+func (room *Room[RoomId, PlayerID]) label() string {
 	room.mu.RLock()
 	defer room.mu.RUnlock()
+	return room.metricsLabel
+}
+
+// ThrottleStats returns the counters of inbound traffic rejected by the room's rate limit.
+func (room *Room[RoomId, PlayerID]) ThrottleStats() ThrottleStats {
+	return room.throttle.stats()
+}
 
+func (room *Room[RoomId, PlayerID]) sessionConfig() sessionConfig {
+	return sessionConfig{
+		rateLimit:      room.opts.RateLimit.withMaxMessageSize(room.opts.MaxMessageSize),
+		onThrottle:     room.onThrottle,
+		maxMessageSize: room.opts.MaxMessageSize,
+		metrics:        room.metrics,
+		tracer:         room.tracer,
+		parent:         room.ctx,
+		logger:         room.Slogger,
+		payloads:       room.opts.LogPayloads,
+		clock:          room.clock,
+		room:           room.metricsLabel,
+		done:           room.ctx.Done(),
+	}
+}
+
+func (room *Room[RoomId, PlayerID]) onThrottle(action RateLimitAction, size int) {
+	room.throttle.record(action, size)
+	room.metrics.MessageThrottled(room.metricsLabel, size)
+}
+
+func (room *Room[RoomId, PlayerID]) handleMessage(ctx context.Context, player PlayerID, message []byte) {
+	// Validation comes first so that middlewares only see valid messages.
+	if room.opts.ValidateMessage != nil {
+		if err := room.opts.ValidateMessage(player, message); err != nil {
+			room.Slogger.Debug("message rejected", "func", "room.handleMessage", "player", player, "err", err)
+			room.SendMessageToPlayerContext(ctx, player, room.errorReply(err))
+			return
+		}
+	}
+	room.mu.RLock()
+	handler := room.handler
+	room.mu.RUnlock()
+	handler(&InboundMessage[RoomId, PlayerID]{
+		Context: ctx,
+		Room:    room,
+		Player:  player,
+		Message: message,
+	})
+}
+
+// dispatchMessage is the end of the middleware chain. A decoded value travels to OnMessageContext in the context.
+func (room *Room[RoomId, PlayerID]) dispatchMessage(msg *InboundMessage[RoomId, PlayerID]) {
+	if room.onMessage == nil {
+		return
+	}
+	ctx := msg.Context
+	if msg.Decoded != nil {
+		ctx = context.WithValue(ctx, decodedKey{}, msg.Decoded)
+	}
+	room.onMessage(ctx, msg.Player, msg.Message)
+}
+
+func (room *Room[RoomId, PlayerID]) errorReply(err error) []byte {
+	if room.opts.ErrorReply != nil {
+		return room.opts.ErrorReply(err)
+	}
+	return DefaultErrorReply(err)
+}
+
+// SendMessageToPlayer sends a message to the player. With a Broker, a player who is not connected to this node is
+// sent the message through the node they are connected to.
+func (room *Room[RoomId, PlayerID]) SendMessageToPlayer(player PlayerID, message []byte) {
+	room.SendMessageToPlayerContext(room.ctx, player, message)
+}
+
+// SendMessageToPlayerContext is SendMessageToPlayer with a context for tracing, usually the one passed to
+// OnMessageContext.
+func (room *Room[RoomId, PlayerID]) SendMessageToPlayerContext(ctx context.Context, player PlayerID, message []byte) {
+	if !room.sendToLocalPlayer(ctx, player, message) && room.cluster != nil {
+		room.cluster.sendPlayer(player, message)
+	}
+}
+
+// sendToLocalPlayer sends a message to the player if they are connected to this node.
+func (room *Room[RoomId, PlayerID]) sendToLocalPlayer(ctx context.Context, player PlayerID, message []byte) bool {
+	sl := room.Slogger.With("func", "room.SendMessageToPlayer")
+	sl.Debug("sending message", "player", player, room.opts.LogPayloads.attr(message))
+	room.mu.RLock()
 	ps, ok := room.players[player]
-	if !ok {
+	interceptors := room.interceptors
+	room.mu.RUnlock()
+
+	if !ok || ps == nil {
 		sl.Debug("player not found", "player", player)
-		return
+		return false
 	}
-	ps.Send(message)
+	room.deliver(ctx, ps, player, message, interceptors)
+	return true
 }
 
+// SendMessageToAllPlayers sends a message to every connected player, on every node when the room has a Broker.
 func (room *Room[RoomId, PlayerID]) SendMessageToAllPlayers(message []byte) {
+	room.SendMessageToAllPlayersContext(room.ctx, message)
+}
+
+// SendMessageToAllPlayersContext is SendMessageToAllPlayers with a context for tracing, usually the one passed to
+// OnMessageContext.
+func (room *Room[RoomId, PlayerID]) SendMessageToAllPlayersContext(ctx context.Context, message []byte) {
+	room.sendToLocalPlayers(ctx, message)
+	if room.cluster != nil {
+		room.cluster.sendAll(message)
+	}
+}
+
+// sendToLocalPlayers sends a message to every player connected to this node.
+func (room *Room[RoomId, PlayerID]) sendToLocalPlayers(ctx context.Context, message []byte) {
 	sl := room.Slogger.With("func", "room.SendMessageToAllPlayers")
-	room.mu.RLock()
-	for _, p := range room.players {
-		if p == nil {
-			continue
-		}
+	sessions, interceptors := room.connectedSessions()
+	ctx, span := room.tracer.Start(ctx, "goroom.broadcast",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.recipients", len(sessions)),
+		Attr("goroom.message.size", len(message)),
+	)
+	defer span.End()
+	for _, p := range sessions {
 		sl.Debug("sending message", "player", p.ReferenceID())
-		p.Send(message)
+		room.deliver(ctx, p, p.ReferenceID(), message, interceptors)
 	}
-	room.mu.RUnlock()
 }
 
 func (room *Room[RoomId, PlayerID]) CleanUpPlayers() {
+	room.CleanUpPlayersOlderThan(room.cleanupPeriod)
+}
+
+// CleanUpPlayersOlderThan removes players who have been disconnected for longer than age, as the periodic cleanup
+// does with the room's CleanupPeriod. An age of zero removes every disconnected player.
+func (room *Room[RoomId, PlayerID]) CleanUpPlayersOlderThan(age time.Duration) {
+	room.mu.Lock()
+	defer room.unlock()
 	if room.Status != Open {
 		return
 	}
 	sl := room.Slogger.With("func", "room.CleanUpPlayers")
 	sl.Debug("starting")
-	room.mu.Lock()
-	defer room.mu.Unlock()
 
+	now := room.clock.Now()
 	for playerID, p := range room.players {
-		if p == nil && time.Since(room.lastSeen[playerID]) > room.cleanupPeriod {
+		if p == nil && now.Sub(room.lastSeen[playerID]) > age {
 			sl.Info("removing", "player", playerID,
 				slog.Group("checks",
 					"lastSeen", room.lastSeen[playerID],
-					"timeSince", time.Since(room.lastSeen[playerID]),
-					"cleanupPeriod", room.cleanupPeriod,
-					"cleanupPeriodExceeded", time.Since(room.lastSeen[playerID]) > room.cleanupPeriod,
+					"timeSince", now.Sub(room.lastSeen[playerID]),
+					"cleanupPeriod", age,
 				))
-			delete(room.players, playerID)
-			go func(pid PlayerID) {
-				if room.opts.OnRemove == nil {
-					return
-				}
-				room.opts.OnRemove(pid)
-			}(playerID)
+			room.removePlayer(playerID)
 		}
 	}
 
 	sl.Debug("finished")
 }
 
+// disconnect marks the player disconnected at the given time and publishes the event, returning the context for
+// OnDisconnect. The caller must hold room.mu.
+func (room *Room[RoomId, PlayerID]) disconnect(pid PlayerID, at time.Time) context.Context {
+	room.players[pid] = nil
+	room.lastSeen[pid] = at
+	if pc, ok := room.contexts[pid]; ok {
+		pc.cancel()
+	}
+	room.metrics.PlayerDisconnected(room.metricsLabel)
+	room.publish(EventPlayerDisconnected, pid)
+	return room.playerContext(pid)
+}
+
+// removePlayer forgets the player and calls OnRemove. The caller must hold room.mu.
+func (room *Room[RoomId, PlayerID]) removePlayer(pid PlayerID) {
+	ctx := room.playerContext(pid)
+	if pc, ok := room.contexts[pid]; ok {
+		pc.cancel()
+		delete(room.contexts, pid)
+	}
+	delete(room.players, pid)
+	delete(room.lastSeen, pid)
+	room.metrics.PlayerRemoved(room.metricsLabel)
+	room.publish(EventPlayerRemoved, pid)
+	if room.onRemove != nil {
+		room.goCallback(ctx, "OnRemove", pid, func(ctx context.Context) { room.onRemove(ctx, pid) })
+	}
+}
+
+// SetStatus changes the room's status, on every node when the room has a Broker.
 func (room *Room[RoomId, PlayerID]) SetStatus(status RoomStatus) {
-	if room.Status == status {
-		return
+	if room.setStatus(status) && room.cluster != nil {
+		room.cluster.sendStatus(status)
 	}
-	sl := room.Slogger.With("func", "room.SetStatus")
-	sl.Debug("setting status", "status", status)
+}
+
+// setStatus changes the room's status on this node, reporting whether it changed.
+func (room *Room[RoomId, PlayerID]) setStatus(status RoomStatus) bool {
 	room.mu.Lock()
-	defer room.mu.Unlock()
+	defer room.unlock()
+	if room.Status == status {
+		return false
+	}
+	room.Slogger.Debug("setting status", "func", "room.SetStatus", "status", status)
 	room.Status = status
+	room.publishStatus(status)
 	if status == Locked {
 		// Remove disconnected players
 		for pid, p := range room.players {
@@ -256,11 +591,10 @@ func (room *Room[RoomId, PlayerID]) SetStatus(status RoomStatus) {
 				continue
 			}
 			// Remove reference to previously connected players
-			delete(room.players, pid)
-			delete(room.lastSeen, pid)
-			go room.opts.OnRemove(pid)
+			room.removePlayer(pid)
 		}
 	}
+	return true
 }
 
 func (room *Room[RoomId, PlayerID]) SetRoomID(newID RoomId) {
@@ -272,16 +606,13 @@ func (room *Room[RoomId, PlayerID]) SetRoomID(newID RoomId) {
 	}
 
 	room.ID = newID
-	if room.opts.Slogger != nil {
-		room.Slogger = room.opts.Slogger.With("room", room.ID)
-	} else {
-		room.Slogger = slog.Default().With("room", room.ID)
-	}
+	room.metricsLabel = fmt.Sprint(room.ID)
+	room.Slogger = room.newLogger()
 }
 
 func (room *Room[RoomId, PlayerID]) SetPlayers(players []PlayerID) error {
 	room.mu.Lock()
-	defer room.mu.Unlock()
+	defer room.unlock()
 	for _, pid := range players {
 		_, ok := room.players[pid]
 		if ok {
@@ -297,10 +628,7 @@ func (room *Room[RoomId, PlayerID]) SetPlayers(players []PlayerID) error {
 				continue
 			}
 			ss.Close()
-			room.players[pid] = nil
-			delete(room.players, pid)
-			delete(room.lastSeen, pid)
-			go room.opts.OnRemove(pid)
+			room.removePlayer(pid)
 			//playersToRemove = append(playersToRemove, pid)
 		}
 	}
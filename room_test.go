@@ -2,11 +2,16 @@ package goroom
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/gobwas/ws/wsutil"
 )
 
 // mockSocketSession provides a way to simulate a SocketSession for testing purposes.
@@ -100,14 +105,14 @@ func setupTestRoom[PlayerID comparable](t *testing.T, roomID string) (*Room[stri
 
 	handler := newMockHandler[PlayerID]()
 
-	room := NewRoom[string, PlayerID](context.Background(), roomID, Options[PlayerID]{
+	room, err := NewRoom[string, PlayerID](context.Background(), roomID, Options[PlayerID]{
 		OnConnect:    handler.OnConnect,
 		OnDisconnect: handler.OnDisconnect,
 		OnMessage:    handler.OnMessage,
 		OnRemove:     handler.OnClose,
 	})
-	if room == nil {
-		t.Fatal("NewRoom returned nil")
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
 	}
 
 	// The run method is started in a goroutine, so we need to give it a moment to initialize.
@@ -143,15 +148,15 @@ func TestNewRoom(t *testing.T) {
 	t.Run("should set the cleanup period", func(t *testing.T) {
 		handler := newMockHandler[string]()
 
-		room := NewRoom[string, string](context.Background(), "roomCleanup", Options[string]{
+		room, err := NewRoom[string, string](context.Background(), "roomCleanup", Options[string]{
 			OnConnect:     handler.OnConnect,
 			OnDisconnect:  handler.OnDisconnect,
 			OnMessage:     handler.OnMessage,
 			OnRemove:      handler.OnClose,
 			CleanupPeriod: time.Second,
 		})
-		if room == nil {
-			t.Fatal("NewRoom returned nil")
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
 		}
 
 		// The run method is started in a goroutine, so we need to give it a moment to initialize.
@@ -191,6 +196,111 @@ func TestRoom_Run_Close(t *testing.T) {
 			t.Fatal("room did not close within the expected time")
 		}
 	})
+	t.Run("should be safe to stop more than once and report when done", func(t *testing.T) {
+		room, _, _ := setupTestRoom[int](t, "test-room-stop-twice")
+		started := make(chan error, 1)
+		go func() { started <- room.Start() }()
+
+		room.Stop()
+		room.Stop()
+		select {
+		case <-room.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected Done to be closed after Stop")
+		}
+		room.Wait()
+		if err := <-started; err != nil && !errors.Is(err, ErrRoomStopped) {
+			t.Errorf("expected Start to return nil or ErrRoomStopped, got %v", err)
+		}
+	})
+	t.Run("should refuse to start twice or after stopping", func(t *testing.T) {
+		room, _, _ := setupTestRoom[int](t, "test-room-restart")
+		go room.Start()
+		deadline := time.After(time.Second)
+		for started := false; !started; {
+			room.mu.RLock()
+			started = room.isStarted
+			room.mu.RUnlock()
+			select {
+			case <-deadline:
+				t.Fatal("timed out waiting for the room to start")
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if err := room.Start(); !errors.Is(err, ErrRoomStarted) {
+			t.Errorf("expected ErrRoomStarted, got %v", err)
+		}
+		room.Stop()
+		if err := room.Start(); !errors.Is(err, ErrRoomStopped) {
+			t.Errorf("expected ErrRoomStopped, got %v", err)
+		}
+		if room.CanJoin(1) {
+			t.Error("expected a stopped room to refuse players")
+		}
+	})
+	t.Run("should not panic when sessions send while stopping", func(t *testing.T) {
+		room, _, _ := setupTestRoom[int](t, "test-room-stop-sending")
+		go room.Start()
+		for id := 1; id <= 5; id++ {
+			serverConn, clientConn := net.Pipe()
+			t.Cleanup(func() { _ = clientConn.Close() })
+			if _, err := room.admit(context.Background(), id, func(cfg sessionConfig) SocketSessioner[int] {
+				return newSocketSession(serverConn, id, room.messages, cfg)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				for wsutil.WriteClientBinary(clientConn, []byte("spam")) == nil {
+				}
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		room.Stop()
+		select {
+		case <-room.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected the room to stop while sessions were sending")
+		}
+	})
+	t.Run("should disconnect every connected player once", func(t *testing.T) {
+		var disconnects sync.Map
+		var count atomic.Int32
+		room, err := NewRoom[string, int](context.Background(), "test-room-stop-disconnects", Options[int]{
+			OnDisconnect: func(player int) {
+				count.Add(1)
+				if _, dup := disconnects.LoadOrStore(player, true); dup {
+					t.Errorf("expected one OnDisconnect for player %d", player)
+				}
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		events, _ := room.Subscribe(32)
+		go room.Start()
+		const players = 10
+		for id := 1; id <= players; id++ {
+			if _, err := room.ConnectMemory(context.Background(), id); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		room.Stop()
+		room.Wait()
+		if got := count.Load(); got != players {
+			t.Errorf("expected %d OnDisconnect calls, got %d", players, got)
+		}
+		disconnected := 0
+		for e := range events {
+			if e.Type == EventPlayerDisconnected {
+				disconnected++
+			}
+		}
+		if disconnected != players {
+			t.Errorf("expected %d disconnect events, got %d", players, disconnected)
+		}
+	})
 }
 
 func TestRoom_GetPlayerPresences(t *testing.T) {
@@ -345,7 +455,10 @@ func TestRoom_CleanUpPlayers(t *testing.T) {
 func TestRoom_SetRoomId(t *testing.T) {
 	t.Run("should set the room ID", func(t *testing.T) {
 		ctx := context.Background()
-		room := NewRoom[string, string](ctx, "initial-id", Options[string]{})
+		room, err := NewRoom[string, string](ctx, "initial-id", Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
 
 		newID := "new-id"
 		room.SetRoomID(newID)
@@ -363,9 +476,12 @@ func TestRoom_SetRoomId(t *testing.T) {
 
 		ctx := context.Background()
 		initID := "initial-id"
-		room := NewRoom[string, string](ctx, initID, Options[string]{
+		room, err := NewRoom[string, string](ctx, initID, Options[string]{
 			Slogger: sl,
 		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
 
 		newID := "new-id"
 		room.SetRoomID(newID)
@@ -386,7 +502,10 @@ func TestRoom_SetRoomId(t *testing.T) {
 		ctx, cancel := context.WithCancel(t.Context())
 		defer cancel()
 		initID := "initial-id"
-		room := NewRoom[string, string](ctx, initID, Options[string]{})
+		room, err := NewRoom[string, string](ctx, initID, Options[string]{})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
 
 		go room.Start()
 
@@ -494,3 +613,89 @@ func TestRoom_SetPlayers(t *testing.T) {
 		}
 	})
 }
+
+func TestRoom_ValidateMessage(t *testing.T) {
+	t.Run("should reply with an error and not call OnMessage for invalid messages", func(t *testing.T) {
+		handler := newMockHandler[string]()
+		room, err := NewRoom[string, string](context.Background(), "test-room-validate", Options[string]{
+			OnConnect:    handler.OnConnect,
+			OnDisconnect: handler.OnDisconnect,
+			OnMessage:    handler.OnMessage,
+			OnRemove:     handler.OnClose,
+			ValidateMessage: func(player string, message []byte) error {
+				if !strings.HasPrefix(string(message), "{") {
+					return errors.New("expected json object")
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		ss := newMockSocketSession[string]("player-1")
+		room.players["player-1"] = ss
+
+		room.handleMessage(context.Background(), "player-1", []byte("not json"))
+		room.handleMessage(context.Background(), "player-1", []byte(`{"ok":true}`))
+
+		msgResults := handler.GetOnMessageResults()
+		if len(msgResults) != 1 || string(msgResults[0].Message) != `{"ok":true}` {
+			t.Errorf("expected only the valid message to reach OnMessage, got %v", msgResults)
+		}
+		if len(ss.sentMessages) != 1 {
+			t.Fatalf("expected 1 error reply, got %d", len(ss.sentMessages))
+		}
+		if string(ss.sentMessages[0]) != `{"type":"error","error":"expected json object"}` {
+			t.Errorf("unexpected error reply %s", ss.sentMessages[0])
+		}
+	})
+	t.Run("should use the ErrorReply option when set", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-validate", Options[string]{
+			ValidateMessage: func(player string, message []byte) error {
+				return errors.New("nope")
+			},
+			ErrorReply: func(err error) []byte {
+				return []byte("ERR " + err.Error())
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		ss := newMockSocketSession[string]("player-1")
+		room.players["player-1"] = ss
+
+		room.handleMessage(context.Background(), "player-1", []byte("anything"))
+
+		if len(ss.sentMessages) != 1 || string(ss.sentMessages[0]) != "ERR nope" {
+			t.Errorf("expected custom error reply, got %q", ss.sentMessages)
+		}
+	})
+	t.Run("should validate before the middleware chain", func(t *testing.T) {
+		room, err := NewRoom[string, string](context.Background(), "test-room-validate", Options[string]{
+			ValidateMessage: func(player string, message []byte) error {
+				if string(message) == "bad" {
+					return errors.New("bad message")
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		room.players["player-1"] = newMockSocketSession[string]("player-1")
+		var seen []string
+		room.Use(func(next Handler[string, string]) Handler[string, string] {
+			return func(msg *InboundMessage[string, string]) {
+				seen = append(seen, string(msg.Message))
+				next(msg)
+			}
+		})
+
+		room.handleMessage(context.Background(), "player-1", []byte("bad"))
+		room.handleMessage(context.Background(), "player-1", []byte("good"))
+
+		if len(seen) != 1 || seen[0] != "good" {
+			t.Errorf("expected the middleware to only see the valid message, got %q", seen)
+		}
+	})
+}
@@ -0,0 +1,252 @@
+package goroom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+	ErrNoSnapshotStore  = errors.New("room has no snapshot store")
+)
+
+// Snapshot is the serializable state of a room: enough to recreate it after a restart so that players who were in
+// the room can rejoin. Players connected when the snapshot was taken have a LastSeen of its Time.
+type Snapshot[RoomId comparable, PlayerID comparable] struct {
+	ID      RoomId
+	Status  RoomStatus
+	Players []PlayerPresence[PlayerID]
+	// State is the application's own state, from Options.SnapshotState.
+	State []byte
+	Time  time.Time
+}
+
+type snapshotPlayerJSON[PlayerID comparable] struct {
+	ID       PlayerID  `json:"id"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+type snapshotJSON[RoomId comparable, PlayerID comparable] struct {
+	ID      RoomId                         `json:"id"`
+	Status  string                         `json:"status"`
+	Players []snapshotPlayerJSON[PlayerID] `json:"players"`
+	State   []byte                         `json:"state,omitempty"`
+	Time    time.Time                      `json:"time"`
+}
+
+func (s Snapshot[RoomId, PlayerID]) MarshalJSON() ([]byte, error) {
+	v := snapshotJSON[RoomId, PlayerID]{
+		ID:      s.ID,
+		Status:  s.Status.String(),
+		Players: make([]snapshotPlayerJSON[PlayerID], 0, len(s.Players)),
+		State:   s.State,
+		Time:    s.Time,
+	}
+	for _, p := range s.Players {
+		v.Players = append(v.Players, snapshotPlayerJSON[PlayerID]{ID: p.ID, LastSeen: p.LastSeen})
+	}
+	return json.Marshal(v)
+}
+
+func (s *Snapshot[RoomId, PlayerID]) UnmarshalJSON(data []byte) error {
+	var v snapshotJSON[RoomId, PlayerID]
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	status, err := ParseRoomStatus(v.Status)
+	if err != nil {
+		return err
+	}
+	*s = Snapshot[RoomId, PlayerID]{ID: v.ID, Status: status, State: v.State, Time: v.Time}
+	for _, p := range v.Players {
+		s.Players = append(s.Players, PlayerPresence[PlayerID]{ID: p.ID, LastSeen: p.LastSeen})
+	}
+	return nil
+}
+
+// SnapshotStore persists encoded snapshots, keyed by the string form of the room ID. It must be safe for
+// concurrent use. Load returns an error wrapping ErrSnapshotNotFound if there is no snapshot for the key.
+type SnapshotStore interface {
+	Save(ctx context.Context, key string, data []byte) error
+	Load(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// FileSnapshotStore keeps each snapshot in its own file in a directory.
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore returns a store in dir, creating it if necessary.
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSnapshotStore{dir: dir}, nil
+}
+
+func (s *FileSnapshotStore) path(key string) string {
+	return filepath.Join(s.dir, url.PathEscape(key)+".json")
+}
+
+// Save writes the snapshot to a temporary file and renames it into place, so a crash never leaves a partial
+// snapshot behind.
+func (s *FileSnapshotStore) Save(_ context.Context, key string, data []byte) error {
+	f, err := os.CreateTemp(s.dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+func (s *FileSnapshotStore) Load(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, key)
+	}
+	return data, err
+}
+
+func (s *FileSnapshotStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Snapshot captures the room's state, including the application state from Options.SnapshotState.
+func (room *Room[RoomId, PlayerID]) Snapshot() (Snapshot[RoomId, PlayerID], error) {
+	room.mu.RLock()
+	now := room.clock.Now()
+	snap := Snapshot[RoomId, PlayerID]{
+		ID:      room.ID,
+		Status:  room.Status,
+		Players: make([]PlayerPresence[PlayerID], 0, len(room.players)),
+		Time:    now,
+	}
+	for playerID, p := range room.players {
+		lastSeen := room.lastSeen[playerID]
+		if p != nil {
+			lastSeen = now
+		}
+		snap.Players = append(snap.Players, PlayerPresence[PlayerID]{ID: playerID, LastSeen: lastSeen})
+	}
+	room.mu.RUnlock()
+
+	if room.opts.SnapshotState != nil {
+		state, err := room.opts.SnapshotState()
+		if err != nil {
+			return snap, fmt.Errorf("snapshot state: %w", err)
+		}
+		snap.State = state
+	}
+	return snap, nil
+}
+
+// SaveSnapshot saves a snapshot of the room to Options.SnapshotStore. The room does this itself every
+// SnapshotInterval and when it stops.
+func (room *Room[RoomId, PlayerID]) SaveSnapshot(ctx context.Context) error {
+	if room.opts.SnapshotStore == nil {
+		return ErrNoSnapshotStore
+	}
+	snap, err := room.Snapshot()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return room.opts.SnapshotStore.Save(ctx, room.label(), data)
+}
+
+// saveSnapshot is SaveSnapshot for the room's own periodic and final snapshots, which can only log failures.
+func (room *Room[RoomId, PlayerID]) saveSnapshot() {
+	if room.opts.SnapshotStore == nil {
+		return
+	}
+	if err := room.SaveSnapshot(context.Background()); err != nil {
+		room.Slogger.Error("snapshot failed", "func", "room.saveSnapshot", "err", err)
+	}
+}
+
+// goSaveSnapshot takes the room's periodic snapshot on a new goroutine, so that SnapshotState and the store do not
+// hold up the room's messages. The snapshot is skipped if the previous one is still being saved.
+func (room *Room[RoomId, PlayerID]) goSaveSnapshot() {
+	if !room.snapshotMu.TryLock() {
+		room.Slogger.Debug("previous snapshot still saving, skipping", "func", "room.goSaveSnapshot")
+		return
+	}
+	// Counted with the callbacks so that Drain and Wait see the save finish.
+	room.callbacks.add()
+	go func() {
+		defer room.callbacks.done()
+		defer room.snapshotMu.Unlock()
+		if !room.snapshotsDone {
+			room.saveSnapshot()
+		}
+	}()
+}
+
+// saveFinalSnapshot saves the room's snapshot as it stops, after any periodic snapshot in progress so that an older
+// one cannot overwrite it.
+func (room *Room[RoomId, PlayerID]) saveFinalSnapshot() {
+	room.snapshotMu.Lock()
+	defer room.snapshotMu.Unlock()
+	room.saveSnapshot()
+	room.snapshotsDone = true
+}
+
+// NewRoomFromSnapshot creates a room with the snapshot's ID, status and players, all disconnected, and passes its
+// application state to Options.RestoreState. Players are removed by cleanup as usual if they do not reconnect
+// within the CleanupPeriod of when they were last seen.
+func NewRoomFromSnapshot[RoomId comparable, PlayerID comparable](parentCtx context.Context, snap Snapshot[RoomId, PlayerID], options Options[PlayerID]) (*Room[RoomId, PlayerID], error) {
+	room, err := NewRoom[RoomId, PlayerID](parentCtx, snap.ID, options)
+	if err != nil {
+		return nil, err
+	}
+	room.Status = snap.Status
+	for _, p := range snap.Players {
+		room.players[p.ID] = nil
+		room.lastSeen[p.ID] = p.LastSeen
+	}
+	if options.RestoreState != nil && snap.State != nil {
+		if err := options.RestoreState(snap.State); err != nil {
+			room.cancel()
+			return nil, fmt.Errorf("restore state: %w", err)
+		}
+	}
+	return room, nil
+}
+
+// RestoreRoom creates the room from its snapshot in Options.SnapshotStore, as NewRoomFromSnapshot. It returns an
+// error wrapping ErrSnapshotNotFound if there is none, in which case the caller will usually fall back to NewRoom.
+func RestoreRoom[RoomId comparable, PlayerID comparable](parentCtx context.Context, id RoomId, options Options[PlayerID]) (*Room[RoomId, PlayerID], error) {
+	if options.SnapshotStore == nil {
+		return nil, ErrNoSnapshotStore
+	}
+	data, err := options.SnapshotStore.Load(parentCtx, fmt.Sprint(id))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot[RoomId, PlayerID]
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return NewRoomFromSnapshot(parentCtx, snap, options)
+}
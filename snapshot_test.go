@@ -0,0 +1,116 @@
+package goroom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySnapshotStore keeps snapshots in a map.
+type memorySnapshotStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *memorySnapshotStore) Save(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = make(map[string][]byte)
+	}
+	s.data[key] = data
+	return nil
+}
+
+func (s *memorySnapshotStore) Load(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.data[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, key)
+	}
+	return data, nil
+}
+
+func (s *memorySnapshotStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func TestRoom_SnapshotOnStop(t *testing.T) {
+	store, err := NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSnapshotStore returned error: %v", err)
+	}
+	room, err := NewRoom[int, string](context.Background(), 7, Options[string]{
+		SnapshotStore: store,
+		SnapshotState: func() ([]byte, error) { return []byte("board state"), nil },
+		IgnoreCleanup: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	if _, err := room.ConnectMemory(context.Background(), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	room.SetStatus(Locked)
+	// bob is expected but has not connected yet.
+	if err := room.SetPlayers([]string{"alice", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	room.Stop()
+
+	var restoredState []byte
+	restored, err := RestoreRoom[int, string](context.Background(), 7, Options[string]{
+		SnapshotStore: store,
+		RestoreState:  func(state []byte) error { restoredState = state; return nil },
+	})
+	if err != nil {
+		t.Fatalf("RestoreRoom returned error: %v", err)
+	}
+	if restored.ID != 7 || restored.Status != Locked {
+		t.Errorf("expected locked room 7, got room %v %v", restored.ID, restored.Status)
+	}
+	if string(restoredState) != "board state" {
+		t.Errorf("expected the application state to be restored, got '%s'", restoredState)
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if !restored.CanJoin(player) {
+			t.Errorf("expected %s to be able to rejoin", player)
+		}
+	}
+	if restored.CanJoin("carol") {
+		t.Error("expected a new player to be kept out of the locked room")
+	}
+	if p := restored.GetPlayerPresence("alice"); p.IsConnected || p.LastSeen.Before(before.Add(-time.Second)) {
+		t.Errorf("expected alice to be disconnected and last seen when the room stopped, got %+v", p)
+	}
+}
+
+func TestRestoreRoom_Errors(t *testing.T) {
+	_, err := RestoreRoom[string, string](context.Background(), "missing", Options[string]{SnapshotStore: &memorySnapshotStore{}})
+	if !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+	_, err = RestoreRoom[string, string](context.Background(), "missing", Options[string]{})
+	if !errors.Is(err, ErrNoSnapshotStore) {
+		t.Errorf("expected ErrNoSnapshotStore, got %v", err)
+	}
+
+	store := &memorySnapshotStore{}
+	_ = store.Save(context.Background(), "bad", []byte(`{"id":"bad","status":"Open","state":"c3RhdGU="}`))
+	_, err = RestoreRoom[string, string](context.Background(), "bad", Options[string]{
+		SnapshotStore: store,
+		RestoreState:  func(state []byte) error { return errors.New("corrupt") },
+	})
+	if err == nil {
+		t.Error("expected the RestoreState error to be returned")
+	}
+}
@@ -3,9 +3,9 @@ package goroom
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
+	"io"
 	"log/slog"
 	"net"
 	"sync"
@@ -24,6 +24,10 @@ type SocketMessage[PlayerId comparable] struct {
 	ReferenceID PlayerId
 	Type        SocketMessageType
 	Message     []byte
+
+	// ctx and span trace the message from the session to the room. Both may be nil.
+	ctx  context.Context
+	span Span
 }
 
 type SocketSession[PlayerId comparable] struct {
@@ -36,19 +40,109 @@ type SocketSession[PlayerId comparable] struct {
 	send     chan []byte
 	Messages chan SocketMessage[PlayerId]
 
+	// The limits bit
+	cfg     sessionConfig
+	limiter *rateLimiter
+
 	// The concurrency bit
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	writeMu sync.Mutex
+}
+
+// sessionConfig carries the room-level settings that a session enforces on its connection.
+type sessionConfig struct {
+	rateLimit      RateLimit
+	onThrottle     func(action RateLimitAction, size int)
+	maxMessageSize int64
+	metrics        Metrics
+	tracer         Tracer
+	room           string
+	// parent is the player's context; the session's context is cancelled with it.
+	parent context.Context
+	// logger is the parent logger; the session adds its player and connection IDs.
+	logger   *slog.Logger
+	payloads PayloadLogging
+	clock    Clock
+	// done is closed when the room stops, after which messages to the room are dropped. It may be nil.
+	done <-chan struct{}
+}
+
+var ErrMessageTooLarge = errors.New("message too large")
+
+// throttle applies the rate limit to an inbound message of the given size, reporting false if it must not be
+// forwarded to the room and which action the transport should take.
+func (cfg sessionConfig) throttle(sl *slog.Logger, limiter *rateLimiter, size int) (RateLimitAction, bool) {
+	if limiter.allow(size, cfg.clock.Now()) {
+		return RateLimitDrop, true
+	}
+	action := cfg.rateLimit.Action
+	sl.Warn("throttled", "action", action, "size", size)
+	if cfg.onThrottle != nil {
+		cfg.onThrottle(action, size)
+	}
+	return action, false
+}
+
+// inbound reports a message received by any transport and starts its trace to the room.
+func inbound[PlayerId comparable](ctx context.Context, cfg sessionConfig, player PlayerId, msg []byte) SocketMessage[PlayerId] {
+	cfg.metrics.MessageReceived(cfg.room, len(msg))
+	ctx, span := cfg.tracer.Start(ctx, "goroom.message",
+		Attr("goroom.room", cfg.room),
+		Attr("goroom.player", player),
+		Attr("goroom.message.size", len(msg)),
+	)
+	return SocketMessage[PlayerId]{
+		ReferenceID: player,
+		Type:        Message,
+		Message:     msg,
+		ctx:         ctx,
+		span:        span,
+	}
+}
+
+// forward passes a message from a session to the room. It gives up once the room has stopped, as nothing will read
+// it, so that a session never blocks closing down after the room.
+func forward[PlayerId comparable](cfg sessionConfig, messages chan<- SocketMessage[PlayerId], msg SocketMessage[PlayerId]) {
+	select {
+	case messages <- msg:
+	case <-cfg.done:
+		if msg.span != nil {
+			msg.span.End()
+		}
+	}
 }
 
 func NewSocketSession[PlayerId comparable](conn net.Conn, referenceID PlayerId, messages chan SocketMessage[PlayerId]) *SocketSession[PlayerId] {
-	ctx, cancel := context.WithCancel(context.Background())
+	return newSocketSession(conn, referenceID, messages, sessionConfig{})
+}
+
+func newSocketSession[PlayerId comparable](conn net.Conn, referenceID PlayerId, messages chan SocketMessage[PlayerId], cfg sessionConfig) *SocketSession[PlayerId] {
+	if cfg.metrics == nil {
+		cfg.metrics = NopMetrics{}
+	}
+	if cfg.tracer == nil {
+		cfg.tracer = NopTracer{}
+	}
+	if cfg.parent == nil {
+		cfg.parent = context.Background()
+	}
+	if cfg.logger == nil {
+		cfg.logger = slog.Default()
+	}
+	if cfg.clock == nil {
+		cfg.clock = RealClock{}
+	}
+	cfg.logger = cfg.logger.With("player", referenceID, "connection", nextConnectionID())
+	ctx, cancel := context.WithCancel(cfg.parent)
 	s := &SocketSession[PlayerId]{
 		conn:        conn,
 		referenceID: referenceID,
 		send:        make(chan []byte, 255),
 		Messages:    messages,
+		cfg:         cfg,
+		limiter:     newRateLimiter(cfg.rateLimit, cfg.clock.Now()),
 		ctx:         ctx,
 		cancel:      cancel,
 		wg:          sync.WaitGroup{},
@@ -79,48 +173,64 @@ func (s *SocketSession[PlayerId]) Close() {
 }
 
 func (s *SocketSession[PlayerId]) ReadLoop() {
-	sl := slog.With("func", "socket.ReadLoop")
-	sl.Debug("starting", "referenceID", s.referenceID)
+	sl := s.cfg.logger.With("func", "socket.ReadLoop")
+	sl.Debug("starting")
 	defer func() {
 		s.conn.Close()
 		s.cancel()
-		sl.Debug("ReadLoop exited", "referenceID", s.referenceID)
+		sl.Debug("ReadLoop exited")
 	}()
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{s.conn, lockedWriter{mu: &s.writeMu, w: s.conn}}
 	for {
-		msg, _, err := wsutil.ReadClientData(s.conn)
+		msg, err := readClientData(rw, s.cfg.maxMessageSize)
 		if err != nil {
 			var er wsutil.ClosedError
-			if errors.As(err, &er) {
-				sl.Debug("ReadLoop closing", "referenceID", s.referenceID, "reason", er.Reason)
+			if errors.Is(err, ErrMessageTooLarge) {
+				sl.Warn("ReadLoop message too large", "limit", s.cfg.maxMessageSize)
+				s.writeClose(ws.StatusMessageTooBig, err.Error())
+			} else if errors.As(err, &er) {
+				sl.Debug("ReadLoop closing", "reason", er.Reason)
 			} else {
-				sl.Error("ReadLoop error", "referenceID", s.referenceID, "err", err)
+				sl.Error("ReadLoop error", "err", err)
 			}
 			// send the disconnect message for ANY error that terminates the loop.
-			s.Messages <- s.unregisterMessage()
+			forward(s.cfg, s.Messages, s.unregisterMessage())
 			return
 		}
-		sl.Debug("ReadLoop message", "referenceID", s.referenceID, "message", fmt.Sprintf("%v", msg))
+		sl.Debug("ReadLoop message", s.cfg.payloads.attr(msg))
 
-		sm := SocketMessage[PlayerId]{
-			ReferenceID: s.referenceID,
-			Type:        Message,
-			Message:     msg,
+		if action, ok := s.cfg.throttle(sl, s.limiter, len(msg)); !ok {
+			switch action {
+			case RateLimitWarn:
+				if s.cfg.rateLimit.WarnMessage != nil {
+					s.Send(s.cfg.rateLimit.WarnMessage)
+				}
+			case RateLimitKick:
+				s.writeClose(ws.StatusPolicyViolation, "rate limit exceeded")
+				forward(s.cfg, s.Messages, s.unregisterMessage())
+				return
+			}
+			continue
 		}
 
-		s.Messages <- sm
-		sl.Debug("ReadLoop message sent", "referenceID", s.referenceID, "socket message", fmt.Sprintf("%v", sm))
+		sm := inbound(s.ctx, s.cfg, s.referenceID, msg)
+		forward(s.cfg, s.Messages, sm)
+		sl.Debug("ReadLoop message sent")
 	}
 }
 
 func (s *SocketSession[PlayerId]) WriteLoop() {
-	sl := slog.With("func", "socket.WriteLoop")
-	sl.Debug("starting", "referenceID", s.referenceID)
-	ticker := time.NewTicker(time.Second * 10)
+	sl := s.cfg.logger.With("func", "socket.WriteLoop")
+	sl.Debug("starting")
+	ticker := s.cfg.clock.NewTicker(time.Second * 10)
 	defer func() {
 		ticker.Stop()
 		s.conn.Close()
 		s.cancel()
-		sl.Debug("WriteLoop exited", "referenceID", s.referenceID)
+		sl.Debug("WriteLoop exited")
 	}()
 	for {
 		select {
@@ -128,14 +238,17 @@ func (s *SocketSession[PlayerId]) WriteLoop() {
 			if !ok {
 				return
 			}
-			wsutil.WriteServerBinary(s.conn, msg)
-		case <-ticker.C:
-			sl.Log(context.Background(), slog.Level(-8), "ping",
-				slog.Group("player",
-					"id", s.referenceID,
-				),
-			)
+			s.writeMu.Lock()
+			err := wsutil.WriteServerBinary(s.conn, msg)
+			s.writeMu.Unlock()
+			if err == nil {
+				s.cfg.metrics.MessageSent(s.cfg.room, len(msg))
+			}
+		case <-ticker.C():
+			sl.Log(context.Background(), slog.Level(-8), "ping")
+			s.writeMu.Lock()
 			wsutil.WriteServerMessage(s.conn, ws.OpPing, []byte("ping"))
+			s.writeMu.Unlock()
 		case <-s.ctx.Done():
 			// EXIT AND CLOSE SOCKET SENT FROM ABOVE
 			//s.Messages <- s.unregisterMessage()
@@ -144,6 +257,76 @@ func (s *SocketSession[PlayerId]) WriteLoop() {
 	}
 }
 
+// readClientData reads the next data message from the client, in the same way as wsutil.ReadClientData, but stops
+// reading once the message exceeds maxSize bytes. A maxSize of 0 means there is no limit.
+func readClientData(rw io.ReadWriter, maxSize int64) ([]byte, error) {
+	controlHandler := wsutil.ControlFrameHandler(rw, ws.StateServerSide)
+	rd := wsutil.Reader{
+		Source:         rw,
+		State:          ws.StateServerSide,
+		CheckUTF8:      true,
+		MaxFrameSize:   maxSize,
+		OnIntermediate: controlHandler,
+	}
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			if errors.Is(err, wsutil.ErrFrameTooLarge) {
+				return nil, ErrMessageTooLarge
+			}
+			return nil, err
+		}
+		if hdr.OpCode.IsControl() {
+			if err := controlHandler(hdr, &rd); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if hdr.OpCode&(ws.OpText|ws.OpBinary) == 0 {
+			if err := rd.Discard(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var src io.Reader = &rd
+		if maxSize > 0 {
+			// Read one byte past the limit so that fragmented messages over the limit can be detected.
+			src = io.LimitReader(&rd, maxSize+1)
+		}
+		bts, err := io.ReadAll(src)
+		if errors.Is(err, wsutil.ErrFrameTooLarge) || (maxSize > 0 && int64(len(bts)) > maxSize) {
+			return nil, ErrMessageTooLarge
+		}
+		return bts, err
+	}
+}
+
+// writeClose sends a close frame with the given status. The caller is responsible for closing the connection.
+func (s *SocketSession[PlayerId]) writeClose(code ws.StatusCode, reason string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = ws.WriteFrame(s.conn, ws.NewCloseFrame(ws.NewCloseFrameBody(code, reason)))
+}
+
+// closeGoingAway tells the client that the server is going away with a 1001 close, then closes the session.
+func (s *SocketSession[PlayerId]) closeGoingAway(reason string) {
+	s.writeClose(ws.StatusGoingAway, reason)
+	s.Close()
+}
+
+// lockedWriter serialises writes made by the reader (control frame replies) with those made by the WriteLoop.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
 func (s *SocketSession[PlayerId]) unregisterMessage() SocketMessage[PlayerId] {
 	return SocketMessage[PlayerId]{
 		ReferenceID: s.referenceID,
@@ -152,6 +335,13 @@ func (s *SocketSession[PlayerId]) unregisterMessage() SocketMessage[PlayerId] {
 	}
 }
 
+// Send queues a message for the WriteLoop, waiting while the send buffer is full so that no message is lost. Once
+// the session has closed, the message is dropped instead.
 func (s *SocketSession[PlayerId]) Send(message []byte) {
-	s.send <- message
+	select {
+	case s.send <- message:
+	case <-s.ctx.Done():
+		s.cfg.logger.Debug("session closed, dropping message", "func", "socket.Send")
+		s.cfg.metrics.SendDropped(s.cfg.room)
+	}
 }
@@ -192,3 +192,66 @@ func Test_unregisterMessage(t *testing.T) {
 		}
 	})
 }
+
+func TestSocketSession_MaxMessageSize(t *testing.T) {
+	setup := func(t *testing.T, maxSize int64) (net.Conn, chan SocketMessage[string]) {
+		serverConn, clientConn := net.Pipe()
+		messages := make(chan SocketMessage[string], 10)
+		session := newSocketSession(serverConn, "player1", messages, sessionConfig{maxMessageSize: maxSize})
+		t.Cleanup(func() {
+			session.Close()
+			_ = clientConn.Close()
+		})
+		return clientConn, messages
+	}
+	expectClose := func(t *testing.T, clientConn net.Conn, messages chan SocketMessage[string]) {
+		frame, err := ws.ReadFrame(clientConn)
+		if err != nil {
+			t.Fatalf("Failed to read close frame: %v", err)
+		}
+		if frame.Header.OpCode != ws.OpClose {
+			t.Fatalf("Expected close frame, got %v", frame.Header.OpCode)
+		}
+		if code, _ := ws.ParseCloseFrameData(frame.Payload); code != ws.StatusMessageTooBig {
+			t.Errorf("Expected close code %d, got %d", ws.StatusMessageTooBig, code)
+		}
+		select {
+		case msg := <-messages:
+			if msg.Type != Disconnect {
+				t.Errorf("Expected message type to be Disconnect, got %v", msg.Type)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timed out waiting for disconnect message")
+		}
+	}
+
+	t.Run("should forward messages within the limit", func(t *testing.T) {
+		clientConn, messages := setup(t, 8)
+		if err := wsutil.WriteClientBinary(clientConn, []byte("12345678")); err != nil {
+			t.Fatalf("Failed to write client message: %v", err)
+		}
+		select {
+		case msg := <-messages:
+			if string(msg.Message) != "12345678" {
+				t.Errorf("Expected message to be '12345678', got '%s'", msg.Message)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("Timed out waiting for message from ReadLoop")
+		}
+	})
+	t.Run("should close with 1009 when a frame exceeds the limit", func(t *testing.T) {
+		clientConn, messages := setup(t, 8)
+		go func() {
+			_ = wsutil.WriteClientBinary(clientConn, []byte("123456789"))
+		}()
+		expectClose(t, clientConn, messages)
+	})
+	t.Run("should close with 1009 when fragments together exceed the limit", func(t *testing.T) {
+		clientConn, messages := setup(t, 8)
+		go func() {
+			_ = ws.WriteFrame(clientConn, ws.MaskFrame(ws.NewFrame(ws.OpBinary, false, []byte("12345"))))
+			_ = ws.WriteFrame(clientConn, ws.MaskFrame(ws.NewFrame(ws.OpContinuation, true, []byte("6789"))))
+		}()
+		expectClose(t, clientConn, messages)
+	})
+}
@@ -0,0 +1,256 @@
+package goroom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrNotConnected = errors.New("player is not connected")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+)
+
+// SSESession is a fallback transport for clients that cannot use websockets. Messages to the player are streamed as
+// server-sent events and messages from the player arrive as HTTP POST requests, see Room.HandleSSEWithPlayer.
+type SSESession[PlayerId comparable] struct {
+	referenceID PlayerId
+
+	// The message bit
+	send     chan []byte
+	Messages chan SocketMessage[PlayerId]
+
+	// The limits bit
+	cfg       sessionConfig
+	limiterMu sync.Mutex
+	limiter   *rateLimiter
+
+	// The concurrency bit
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newSSESession[PlayerId comparable](referenceID PlayerId, messages chan SocketMessage[PlayerId], cfg sessionConfig) *SSESession[PlayerId] {
+	cfg.logger = cfg.logger.With("player", referenceID, "connection", nextConnectionID())
+	ctx, cancel := context.WithCancel(cfg.parent)
+	return &SSESession[PlayerId]{
+		referenceID: referenceID,
+		send:        make(chan []byte, 255),
+		Messages:    messages,
+		cfg:         cfg,
+		limiter:     newRateLimiter(cfg.rateLimit, cfg.clock.Now()),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *SSESession[PlayerId]) ReferenceID() PlayerId {
+	return s.referenceID
+}
+
+// Send queues a message for the event stream, dropping it if the send buffer is full.
+func (s *SSESession[PlayerId]) Send(message []byte) {
+	select {
+	case s.send <- message:
+	default:
+		s.cfg.logger.Warn("send buffer full, dropping message", "func", "sse.Send")
+		s.cfg.metrics.SendDropped(s.cfg.room)
+	}
+}
+
+// Close ends the event stream and waits for it to finish.
+func (s *SSESession[PlayerId]) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// stream writes queued messages to the response until the request or the session ends. Text messages are sent as
+// "message" events, with one data line per line of the message. Messages that are not valid UTF-8 are sent as
+// "binary" events with base64 data.
+func (s *SSESession[PlayerId]) stream(w io.Writer, flusher http.Flusher, reqCtx context.Context) {
+	sl := s.cfg.logger.With("func", "sse.stream")
+	sl.Debug("starting")
+	ticker := s.cfg.clock.NewTicker(time.Second * 10)
+	defer func() {
+		ticker.Stop()
+		s.cancel()
+		forward(s.cfg, s.Messages, SocketMessage[PlayerId]{ReferenceID: s.referenceID, Type: Disconnect})
+		close(s.done)
+		sl.Debug("stream exited")
+	}()
+	bw := bufio.NewWriter(w)
+	for {
+		select {
+		case msg := <-s.send:
+			writeEvent(bw, msg)
+			if err := bw.Flush(); err != nil {
+				sl.Debug("stream closing", "err", err)
+				return
+			}
+			s.cfg.metrics.MessageSent(s.cfg.room, len(msg))
+		case <-ticker.C():
+			sl.Log(context.Background(), slog.Level(-8), "ping")
+			if _, err := io.WriteString(bw, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		case <-reqCtx.Done():
+			sl.Debug("stream closing", "reason", "client went away")
+			return
+		case <-s.ctx.Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w *bufio.Writer, msg []byte) {
+	if !utf8.Valid(msg) {
+		w.WriteString("event: binary\ndata: ")
+		w.WriteString(base64.StdEncoding.EncodeToString(msg))
+		w.WriteString("\n\n")
+		return
+	}
+	for _, line := range bytes.Split(msg, []byte("\n")) {
+		w.WriteString("data: ")
+		w.Write(bytes.TrimSuffix(line, []byte("\r")))
+		w.WriteString("\n")
+	}
+	w.WriteString("\n")
+}
+
+// receive forwards a message posted by the player to the room, applying the room's limits.
+func (s *SSESession[PlayerId]) receive(msg []byte) error {
+	sl := s.cfg.logger.With("func", "sse.receive")
+	sl.Debug("message", s.cfg.payloads.attr(msg))
+	s.limiterMu.Lock()
+	action, ok := s.cfg.throttle(sl, s.limiter, len(msg))
+	s.limiterMu.Unlock()
+	if !ok {
+		switch action {
+		case RateLimitWarn:
+			if s.cfg.rateLimit.WarnMessage != nil {
+				s.Send(s.cfg.rateLimit.WarnMessage)
+			}
+		case RateLimitKick:
+			s.cancel()
+		}
+		return ErrRateLimited
+	}
+	select {
+	case <-s.ctx.Done():
+		return ErrNotConnected
+	default:
+	}
+	forward(s.cfg, s.Messages, inbound(s.ctx, s.cfg, s.referenceID, msg))
+	return nil
+}
+
+// HandleSSEWithPlayer is the server-sent events equivalent of HandleSocketWithPlayer. A GET request joins the room
+// and streams messages to the player until the request ends. A POST request sends its body to the room as a message
+// from the player, who must have a stream open, and is answered with 202 Accepted. POST errors passed to onError
+// include ErrNotConnected, ErrRateLimited and ErrMessageTooLarge.
+func (room *Room[RoomId, PlayerId]) HandleSSEWithPlayer(playerID PlayerId, onError ErrorHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			room.streamSSE(playerID, onError, w, r)
+		case http.MethodPost:
+			room.postSSE(playerID, onError, w, r)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func (room *Room[RoomId, PlayerId]) streamSSE(playerID PlayerId, onError ErrorHandler, w http.ResponseWriter, r *http.Request) {
+	ctx, span := room.tracer.Start(r.Context(), "goroom.connect",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.player", playerID),
+		Attr("goroom.transport", "sse"),
+	)
+	var zero PlayerId
+	if playerID == zero {
+		err := ErrNilPlayerID
+		span.RecordError(err)
+		span.End()
+		onError(w, r, err)
+		return
+	}
+	if !room.CanJoin(playerID) {
+		err := ErrCannotJoin
+		span.RecordError(err)
+		span.End()
+		onError(w, r, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		err := errors.New("streaming unsupported")
+		span.RecordError(err)
+		span.End()
+		onError(w, r, err)
+		return
+	}
+	room.Slogger.Info("new sse connection", "player", playerID)
+
+	admitted, err := room.admit(ctx, playerID, func(cfg sessionConfig) SocketSessioner[PlayerId] {
+		return newSSESession[PlayerId](playerID, room.messages, cfg)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		onError(w, r, err)
+		return
+	}
+	ss := admitted.(*SSESession[PlayerId])
+	span.End()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	ss.stream(w, flusher, r.Context())
+}
+
+func (room *Room[RoomId, PlayerId]) postSSE(playerID PlayerId, onError ErrorHandler, w http.ResponseWriter, r *http.Request) {
+	room.mu.RLock()
+	ss, ok := room.players[playerID].(*SSESession[PlayerId])
+	room.mu.RUnlock()
+	if !ok {
+		onError(w, r, ErrNotConnected)
+		return
+	}
+
+	var body io.Reader = r.Body
+	if ss.cfg.maxMessageSize > 0 {
+		body = http.MaxBytesReader(w, r.Body, ss.cfg.maxMessageSize)
+	}
+	msg, err := io.ReadAll(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			err = ErrMessageTooLarge
+		}
+		onError(w, r, err)
+		return
+	}
+	if err := ss.receive(msg); err != nil {
+		onError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,215 @@
+package goroom
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func sseErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrNotConnected):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, ErrRateLimited):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, ErrMessageTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	}
+}
+
+type sseClient struct {
+	t      *testing.T
+	url    string
+	cancel context.CancelFunc
+	events chan string
+}
+
+// connectSSE opens an event stream for the player, returning once the server has admitted them.
+func connectSSE(t *testing.T, srv *httptest.Server, player string) *sseClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"?player="+player, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET returned error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	c := &sseClient{t: t, url: srv.URL + "?player=" + player, cancel: cancel, events: make(chan string, 10)}
+	go func() {
+		defer resp.Body.Close()
+		defer close(c.events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event []string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line != "" {
+				event = append(event, line)
+				continue
+			}
+			if len(event) > 0 && !strings.HasPrefix(event[0], ":") {
+				c.events <- strings.Join(event, "\n")
+			}
+			event = nil
+		}
+	}()
+	t.Cleanup(cancel)
+	return c
+}
+
+func (c *sseClient) next() string {
+	c.t.Helper()
+	select {
+	case event, ok := <-c.events:
+		if !ok {
+			c.t.Fatal("stream closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		c.t.Fatal("timed out waiting for event")
+	}
+	return ""
+}
+
+func (c *sseClient) post(body string) int {
+	c.t.Helper()
+	resp, err := http.Post(c.url, "text/plain", strings.NewReader(body))
+	if err != nil {
+		c.t.Fatalf("POST returned error: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func setupSSERoom(t *testing.T, opts Options[string]) (*Room[string, string], *httptest.Server) {
+	t.Helper()
+	room, err := NewRoom[string, string](context.Background(), "test-room-sse", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	go room.Start()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		room.HandleSSEWithPlayer(r.URL.Query().Get("player"), sseErrorHandler)(w, r)
+	}))
+	t.Cleanup(func() {
+		room.Stop()
+		srv.Close()
+	})
+	return room, srv
+}
+
+func TestRoom_HandleSSEWithPlayer(t *testing.T) {
+	t.Run("should stream messages to the player", func(t *testing.T) {
+		connected := make(chan string, 1)
+		room, srv := setupSSERoom(t, Options[string]{OnConnect: func(player string) { connected <- player }})
+		client := connectSSE(t, srv, "player1")
+		select {
+		case <-connected:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnConnect")
+		}
+
+		room.SendMessageToPlayer("player1", []byte("hello"))
+		room.SendMessageToAllPlayers([]byte("line one\nline two"))
+		room.SendMessageToPlayer("player1", []byte{0xff, 0x00})
+		for _, want := range []string{
+			"data: hello",
+			"data: line one\ndata: line two",
+			"event: binary\ndata: /wA=",
+		} {
+			if got := client.next(); got != want {
+				t.Errorf("expected event %q, got %q", want, got)
+			}
+		}
+	})
+	t.Run("should forward posted messages to the room", func(t *testing.T) {
+		var mu sync.Mutex
+		var received []string
+		done := make(chan struct{})
+		room, srv := setupSSERoom(t, Options[string]{OnMessage: func(player string, message []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, player+":"+string(message))
+			close(done)
+		}})
+		client := connectSSE(t, srv, "player1")
+		if code := client.post("move"); code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d", code)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnMessage")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(received) != 1 || received[0] != "player1:move" {
+			t.Errorf("unexpected messages: %v", received)
+		}
+		if !room.GetPlayerPresence("player1").IsConnected {
+			t.Error("expected player1 to be connected")
+		}
+	})
+	t.Run("should reject posts without a stream", func(t *testing.T) {
+		_, srv := setupSSERoom(t, Options[string]{})
+		c := &sseClient{t: t, url: srv.URL + "?player=player1"}
+		if code := c.post("move"); code != http.StatusConflict {
+			t.Errorf("expected 409, got %d", code)
+		}
+	})
+	t.Run("should enforce the room's limits", func(t *testing.T) {
+		_, srv := setupSSERoom(t, Options[string]{
+			MaxMessageSize: 8,
+			RateLimit:      RateLimit{MessagesPerSecond: 1, MessageBurst: 1, Action: RateLimitKick},
+		})
+		client := connectSSE(t, srv, "player1")
+		if code := client.post("too large message"); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected 413, got %d", code)
+		}
+		if code := client.post("first"); code != http.StatusAccepted {
+			t.Errorf("expected 202, got %d", code)
+		}
+		if code := client.post("second"); code != http.StatusTooManyRequests {
+			t.Errorf("expected 429, got %d", code)
+		}
+		select {
+		case _, ok := <-client.events:
+			if ok {
+				t.Error("expected no events")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected the kicked player's stream to close")
+		}
+	})
+	t.Run("should disconnect the player when the stream closes", func(t *testing.T) {
+		disconnected := make(chan string, 1)
+		room, srv := setupSSERoom(t, Options[string]{OnDisconnect: func(player string) { disconnected <- player }})
+		client := connectSSE(t, srv, "player1")
+		if room.CanJoin("player1") {
+			t.Error("expected a second connection for player1 to be refused")
+		}
+		client.cancel()
+		select {
+		case player := <-disconnected:
+			if player != "player1" {
+				t.Errorf("expected player1 to disconnect, got %s", player)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnDisconnect")
+		}
+		presence := room.GetPlayerPresence("player1")
+		if presence.IsConnected || presence.LastSeen.IsZero() {
+			t.Errorf("expected player1 to be known but disconnected, got %+v", presence)
+		}
+		connectSSE(t, srv, "player1")
+	})
+}
@@ -0,0 +1,319 @@
+package goroom
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHandshakeTimeout = 10 * time.Second
+	defaultMaxHandshakeSize = 4 << 10
+)
+
+// Handshake replies sent by ServeTCP before any other frame. A rejection is followed by the reason as text.
+const (
+	TCPAccepted byte = iota
+	TCPRejected
+)
+
+// TCPOptions configures ServeTCP.
+type TCPOptions[PlayerId comparable] struct {
+	// Authenticate resolves the player from the payload of the connection's first frame. Required.
+	Authenticate func(ctx context.Context, handshake []byte) (PlayerId, error)
+	// HandshakeTimeout limits how long a new connection has to send its handshake. Defaults to 10 seconds.
+	HandshakeTimeout time.Duration
+	// MaxHandshakeSize limits the size of the handshake frame. Defaults to 4KiB.
+	MaxHandshakeSize int64
+}
+
+// ServeTCP accepts connections from native clients that speak length-prefixed frames over a stream socket such as
+// TCP or a Unix socket. Every frame is a 4 byte big-endian length followed by that many bytes of payload, and a
+// zero length frame is a heartbeat that is sent by the server every 10 seconds and ignored by it.
+//
+// The first frame from the client is the handshake, which is passed to Authenticate. The player is then admitted
+// with the same rules as HandleSocketWithPlayer, and the server replies with a frame of TCPAccepted, or TCPRejected
+// followed by the reason before closing the connection.
+//
+// ServeTCP blocks until the listener fails or the room stops, when it closes the listener and returns nil.
+func (room *Room[RoomId, PlayerId]) ServeTCP(ln net.Listener, opts TCPOptions[PlayerId]) error {
+	if opts.Authenticate == nil {
+		return fmt.Errorf("%w: TCPOptions.Authenticate is required", ErrInvalidOptions)
+	}
+	if opts.HandshakeTimeout <= 0 {
+		opts.HandshakeTimeout = defaultHandshakeTimeout
+	}
+	if opts.MaxHandshakeSize <= 0 {
+		opts.MaxHandshakeSize = defaultMaxHandshakeSize
+	}
+	sl := room.Slogger.With("func", "room.ServeTCP", "addr", ln.Addr())
+	sl.Info("listening")
+
+	stop := context.AfterFunc(room.ctx, func() { ln.Close() })
+	defer stop()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if room.ctx.Err() != nil {
+				sl.Info("stopped")
+				return nil
+			}
+			return err
+		}
+		go room.handshakeTCP(conn, opts)
+	}
+}
+
+func (room *Room[RoomId, PlayerId]) handshakeTCP(conn net.Conn, opts TCPOptions[PlayerId]) {
+	ctx, span := room.tracer.Start(room.ctx, "goroom.connect",
+		Attr("goroom.room", room.metricsLabel),
+		Attr("goroom.transport", "tcp"),
+	)
+	defer span.End()
+	sl := room.Slogger.With("func", "room.handshakeTCP", "remote", conn.RemoteAddr())
+
+	reject := func(err error) {
+		sl.Debug("rejected", "err", err)
+		span.RecordError(err)
+		_ = writeFrame(conn, append([]byte{TCPRejected}, err.Error()...))
+		conn.Close()
+	}
+
+	rd := bufio.NewReader(conn)
+	_ = conn.SetDeadline(time.Now().Add(opts.HandshakeTimeout))
+	handshake, err := readFrame(rd, opts.MaxHandshakeSize)
+	if err != nil {
+		reject(fmt.Errorf("handshake: %w", err))
+		return
+	}
+	authCtx, cancel := context.WithDeadline(ctx, time.Now().Add(opts.HandshakeTimeout))
+	playerID, err := opts.Authenticate(authCtx, handshake)
+	cancel()
+	if err != nil {
+		reject(err)
+		return
+	}
+	span.SetAttributes(Attr("goroom.player", playerID))
+
+	var zero PlayerId
+	if playerID == zero {
+		reject(ErrNilPlayerID)
+		return
+	}
+	if !room.CanJoin(playerID) {
+		reject(ErrCannotJoin)
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	_, err = room.admit(ctx, playerID, func(cfg sessionConfig) SocketSessioner[PlayerId] {
+		// The session writes the reply before any message, so the client is only told it has been accepted once the
+		// player is registered.
+		return newTCPSession[PlayerId](conn, rd, []byte{TCPAccepted}, playerID, room.messages, cfg)
+	})
+	if err != nil {
+		reject(err)
+		return
+	}
+	room.Slogger.Info("new tcp connection", "player", playerID)
+}
+
+// TCPSession is a player's connection over ServeTCP.
+type TCPSession[PlayerId comparable] struct {
+	conn        net.Conn
+	rd          *bufio.Reader
+	reply       []byte // the handshake reply, written before any message
+	referenceID PlayerId
+
+	// The message bit
+	send     chan []byte
+	Messages chan SocketMessage[PlayerId]
+
+	// The limits bit
+	cfg     sessionConfig
+	limiter *rateLimiter
+
+	// The concurrency bit
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newTCPSession[PlayerId comparable](conn net.Conn, rd *bufio.Reader, reply []byte, referenceID PlayerId, messages chan SocketMessage[PlayerId], cfg sessionConfig) *TCPSession[PlayerId] {
+	cfg.logger = cfg.logger.With("player", referenceID, "connection", nextConnectionID())
+	ctx, cancel := context.WithCancel(cfg.parent)
+	s := &TCPSession[PlayerId]{
+		conn:        conn,
+		rd:          rd,
+		reply:       reply,
+		referenceID: referenceID,
+		send:        make(chan []byte, 255),
+		Messages:    messages,
+		cfg:         cfg,
+		limiter:     newRateLimiter(cfg.rateLimit, cfg.clock.Now()),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	s.wg.Add(2)
+	go func() {
+		s.readLoop()
+		s.wg.Done()
+	}()
+	go func() {
+		s.writeLoop()
+		s.wg.Done()
+	}()
+	return s
+}
+
+func (s *TCPSession[PlayerId]) ReferenceID() PlayerId {
+	return s.referenceID
+}
+
+func (s *TCPSession[PlayerId]) Close() {
+	s.cancel()
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+// Send queues a message for the writeLoop, dropping it if the send buffer is full.
+func (s *TCPSession[PlayerId]) Send(message []byte) {
+	select {
+	case s.send <- message:
+	default:
+		s.cfg.logger.Warn("send buffer full, dropping message", "func", "tcp.Send")
+		s.cfg.metrics.SendDropped(s.cfg.room)
+	}
+}
+
+func (s *TCPSession[PlayerId]) readLoop() {
+	sl := s.cfg.logger.With("func", "tcp.readLoop")
+	sl.Debug("starting")
+	defer func() {
+		s.conn.Close()
+		s.cancel()
+		forward(s.cfg, s.Messages, SocketMessage[PlayerId]{ReferenceID: s.referenceID, Type: Disconnect})
+		sl.Debug("readLoop exited")
+	}()
+	for {
+		msg, err := readFrame(s.rd, s.cfg.maxMessageSize)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrMessageTooLarge):
+				sl.Warn("readLoop message too large", "limit", s.cfg.maxMessageSize)
+			case errors.Is(err, io.EOF), errors.Is(err, net.ErrClosed):
+				sl.Debug("readLoop closing", "err", err)
+			default:
+				sl.Error("readLoop error", "err", err)
+			}
+			return
+		}
+		if len(msg) == 0 {
+			// heartbeat
+			continue
+		}
+		sl.Debug("readLoop message", s.cfg.payloads.attr(msg))
+
+		if action, ok := s.cfg.throttle(sl, s.limiter, len(msg)); !ok {
+			switch action {
+			case RateLimitWarn:
+				if s.cfg.rateLimit.WarnMessage != nil {
+					s.Send(s.cfg.rateLimit.WarnMessage)
+				}
+			case RateLimitKick:
+				return
+			}
+			continue
+		}
+		forward(s.cfg, s.Messages, inbound(s.ctx, s.cfg, s.referenceID, msg))
+	}
+}
+
+func (s *TCPSession[PlayerId]) writeLoop() {
+	sl := s.cfg.logger.With("func", "tcp.writeLoop")
+	sl.Debug("starting")
+	ticker := s.cfg.clock.NewTicker(time.Second * 10)
+	bw := bufio.NewWriter(s.conn)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+		s.cancel()
+		sl.Debug("writeLoop exited")
+	}()
+	if err := writeFrame(bw, s.reply); err != nil {
+		sl.Debug("handshake reply failed", "err", err)
+		return
+	}
+	if err := bw.Flush(); err != nil {
+		sl.Debug("handshake reply failed", "err", err)
+		return
+	}
+	for {
+		select {
+		case msg := <-s.send:
+			// Batch whatever else is queued into the same write.
+			for n := len(s.send); ; n-- {
+				if err := writeFrame(bw, msg); err != nil {
+					return
+				}
+				s.cfg.metrics.MessageSent(s.cfg.room, len(msg))
+				if n == 0 {
+					break
+				}
+				msg = <-s.send
+			}
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		case <-ticker.C():
+			sl.Log(context.Background(), slog.Level(-8), "ping")
+			if err := writeFrame(bw, nil); err != nil {
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// readFrame reads a length-prefixed frame, failing with ErrMessageTooLarge if it is longer than maxSize. A maxSize
+// of 0 means there is no limit.
+func readFrame(r io.Reader, maxSize int64) ([]byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	size := int64(binary.BigEndian.Uint32(hdr[:]))
+	if maxSize > 0 && size > maxSize {
+		return nil, ErrMessageTooLarge
+	}
+	// Read through a LimitReader rather than allocating size bytes up front, which the peer controls.
+	payload, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) < size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return payload, nil
+}
+
+func writeFrame(w io.Writer, payload []byte) error {
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(payload)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
@@ -0,0 +1,165 @@
+package goroom
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func setupTCPRoom(t *testing.T, opts Options[string]) (*Room[string, string], string) {
+	t.Helper()
+	room, err := NewRoom[string, string](context.Background(), "test-room-tcp", opts)
+	if err != nil {
+		t.Fatalf("NewRoom returned error: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned error: %v", err)
+	}
+	go room.Start()
+	served := make(chan error, 1)
+	go func() {
+		served <- room.ServeTCP(ln, TCPOptions[string]{
+			Authenticate: func(ctx context.Context, handshake []byte) (string, error) {
+				token, ok := bytes.CutPrefix(handshake, []byte("token:"))
+				if !ok {
+					return "", errors.New("bad token")
+				}
+				return string(token), nil
+			},
+		})
+	}()
+	t.Cleanup(func() {
+		room.Stop()
+		select {
+		case err := <-served:
+			if err != nil {
+				t.Errorf("ServeTCP returned error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected ServeTCP to return when the room stops")
+		}
+	})
+	return room, ln.Addr().String()
+}
+
+func dialTCP(t *testing.T, addr, handshake string) (net.Conn, *bufio.Reader, []byte) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := writeFrame(conn, []byte(handshake)); err != nil {
+		t.Fatalf("Failed to write handshake: %v", err)
+	}
+	rd := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	reply, err := readFrame(rd, 0)
+	if err != nil {
+		t.Fatalf("Failed to read handshake reply: %v", err)
+	}
+	return conn, rd, reply
+}
+
+func TestRoom_ServeTCP(t *testing.T) {
+	t.Run("should exchange frames with an authenticated player", func(t *testing.T) {
+		received := make(chan string, 1)
+		room, addr := setupTCPRoom(t, Options[string]{OnMessage: func(player string, message []byte) {
+			received <- player + ":" + string(message)
+		}})
+		conn, rd, reply := dialTCP(t, addr, "token:player1")
+		if !bytes.Equal(reply, []byte{TCPAccepted}) {
+			t.Fatalf("expected accepted, got %q", reply)
+		}
+
+		// A heartbeat is ignored.
+		if err := writeFrame(conn, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFrame(conn, []byte("move")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got := <-received:
+			if got != "player1:move" {
+				t.Errorf("unexpected message: %s", got)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnMessage")
+		}
+
+		room.SendMessageToPlayer("player1", []byte("one"))
+		room.SendMessageToAllPlayers([]byte("two"))
+		for _, want := range []string{"one", "two"} {
+			msg, err := readFrame(rd, 0)
+			if err != nil {
+				t.Fatalf("Failed to read frame: %v", err)
+			}
+			if string(msg) != want {
+				t.Errorf("expected %q, got %q", want, msg)
+			}
+		}
+	})
+	t.Run("should reject failed handshakes and second connections", func(t *testing.T) {
+		_, addr := setupTCPRoom(t, Options[string]{})
+		if _, _, reply := dialTCP(t, addr, "nope"); reply[0] != TCPRejected || string(reply[1:]) != "bad token" {
+			t.Errorf("expected rejection for a bad token, got %q", reply)
+		}
+		dialTCP(t, addr, "token:player1")
+		if _, _, reply := dialTCP(t, addr, "token:player1"); reply[0] != TCPRejected {
+			t.Errorf("expected rejection for a second connection, got %q", reply)
+		}
+	})
+	t.Run("should disconnect the player when the connection closes", func(t *testing.T) {
+		disconnected := make(chan string, 1)
+		room, addr := setupTCPRoom(t, Options[string]{OnDisconnect: func(player string) { disconnected <- player }})
+		conn, _, _ := dialTCP(t, addr, "token:player1")
+		conn.Close()
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnDisconnect")
+		}
+		if room.GetPlayerPresence("player1").IsConnected {
+			t.Error("expected player1 to be disconnected")
+		}
+		if _, _, reply := dialTCP(t, addr, "token:player1"); reply[0] != TCPAccepted {
+			t.Errorf("expected player1 to be able to reconnect, got %q", reply)
+		}
+	})
+	t.Run("should close connections that send oversized frames", func(t *testing.T) {
+		disconnected := make(chan string, 1)
+		_, addr := setupTCPRoom(t, Options[string]{
+			MaxMessageSize: 4,
+			OnDisconnect:   func(player string) { disconnected <- player },
+		})
+		conn, _, _ := dialTCP(t, addr, "token:player1")
+		if err := writeFrame(conn, []byte("too large")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnDisconnect")
+		}
+	})
+}
+
+func TestReadFrame(t *testing.T) {
+	var buf bytes.Buffer
+	_ = writeFrame(&buf, []byte("hello"))
+	if msg, err := readFrame(bytes.NewReader(buf.Bytes()), 5); err != nil || string(msg) != "hello" {
+		t.Errorf("expected hello, got %q %v", msg, err)
+	}
+	if _, err := readFrame(bytes.NewReader(buf.Bytes()), 4); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", err)
+	}
+	if _, err := readFrame(bytes.NewReader(buf.Bytes()[:6]), 0); err == nil {
+		t.Error("expected an error for a truncated frame")
+	}
+}
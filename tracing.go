@@ -0,0 +1,39 @@
+package goroom
+
+import "context"
+
+// Tracer starts spans around connects, inbound messages, callbacks and sends. See the otelgoroom module for an
+// OpenTelemetry adapter.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is a key/value pair attached to a span. Values are usually strings, integers or booleans; adapters
+// format anything else with fmt.Sprint.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// NopTracer creates spans that do nothing. It is the default when Options.Tracer is nil.
+type NopTracer struct{}
+
+func (NopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
@@ -0,0 +1,156 @@
+package goroom
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws/wsutil"
+)
+
+type spanKey struct{}
+
+// recordingTracer records finished spans with the name of their parent span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	ended []*recordedSpan
+}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	name   string
+	parent string
+	attrs  map[string]any
+	err    error
+}
+
+func (rt *recordingTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	span := &recordedSpan{tracer: rt, name: name, attrs: make(map[string]any)}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (rs *recordedSpan) SetAttributes(attrs ...Attribute) {
+	rs.tracer.mu.Lock()
+	defer rs.tracer.mu.Unlock()
+	for _, a := range attrs {
+		rs.attrs[a.Key] = a.Value
+	}
+}
+
+func (rs *recordedSpan) RecordError(err error) {
+	rs.tracer.mu.Lock()
+	defer rs.tracer.mu.Unlock()
+	rs.err = err
+}
+
+func (rs *recordedSpan) End() {
+	rs.tracer.mu.Lock()
+	defer rs.tracer.mu.Unlock()
+	rs.tracer.ended = append(rs.tracer.ended, rs)
+}
+
+func (rt *recordingTracer) find(name string) *recordedSpan {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for _, s := range rt.ended {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestRoom_Tracing(t *testing.T) {
+	t.Run("should trace a message from the session through OnMessageContext to each send", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		done := make(chan struct{})
+		var room *Room[string, string]
+		room, err := NewRoom[string, string](context.Background(), "test-room-tracing", Options[string]{
+			Tracer: tracer,
+			OnMessageContext: func(ctx context.Context, player string, message []byte) {
+				room.SendMessageToAllPlayersContext(ctx, message)
+				close(done)
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+		go room.Start()
+		defer room.Stop()
+
+		serverConn, clientConn := net.Pipe()
+		defer clientConn.Close()
+		room.mu.Lock()
+		room.players["player-1"] = newSocketSession(serverConn, "player-1", room.messages, room.sessionConfig())
+		room.players["player-2"] = newMockSocketSession[string]("player-2")
+		room.mu.Unlock()
+
+		go func() {
+			_ = wsutil.WriteClientBinary(clientConn, []byte("hello"))
+			_, _, _ = wsutil.ReadServerData(clientConn)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for OnMessageContext")
+		}
+		// Spans end as the callbacks unwind.
+		time.Sleep(20 * time.Millisecond)
+
+		expected := map[string]string{
+			"goroom.message":   "",
+			"goroom.OnMessage": "goroom.message",
+			"goroom.broadcast": "goroom.OnMessage",
+			"goroom.send":      "goroom.broadcast",
+		}
+		for name, parent := range expected {
+			span := tracer.find(name)
+			if span == nil {
+				t.Errorf("expected span %s to have ended", name)
+				continue
+			}
+			if span.parent != parent {
+				t.Errorf("expected span %s to have parent %q, got %q", name, parent, span.parent)
+			}
+		}
+		if span := tracer.find("goroom.message"); span != nil && span.attrs["goroom.player"] != "player-1" {
+			t.Errorf("expected message span to carry the player, got %v", span.attrs)
+		}
+	})
+	t.Run("should record panics on the callback span", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		room, err := NewRoom[string, string](context.Background(), "test-room-tracing", Options[string]{
+			Tracer:    tracer,
+			OnConnect: func(player string) { panic("boom") },
+		})
+		if err != nil {
+			t.Fatalf("NewRoom returned error: %v", err)
+		}
+
+		room.runCallback(context.Background(), "OnConnect", "player-1", func(context.Context) { room.opts.OnConnect("player-1") })
+
+		span := tracer.find("goroom.OnConnect")
+		if span == nil || span.err == nil {
+			t.Fatalf("expected OnConnect span with an error, got %+v", span)
+		}
+	})
+}
+
+func TestOptions_OnMessageContext(t *testing.T) {
+	t.Run("should reject setting both OnMessage and OnMessageContext", func(t *testing.T) {
+		_, err := NewRoom[string, string](context.Background(), "test-room-options", Options[string]{
+			OnMessage:        func(player string, message []byte) {},
+			OnMessageContext: func(ctx context.Context, player string, message []byte) {},
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}